@@ -1,40 +1,506 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/goccy/go-yaml/ast"
 	"github.com/goccy/go-yaml/parser"
+	"github.com/richerve/yaml2go/pkg/codegen"
+	"github.com/richerve/yaml2go/pkg/diag"
 	"github.com/richerve/yaml2go/pkg/generator"
+	"github.com/richerve/yaml2go/pkg/golang"
+	"github.com/richerve/yaml2go/pkg/ident"
+	"github.com/richerve/yaml2go/pkg/output"
+	"github.com/richerve/yaml2go/pkg/roundtrip"
+)
+
+// stdinArg is the argument reading the YAML from stdin, stdinName the name
+// its diagnostics are reported with.
+const (
+	stdinArg  = "-"
+	stdinName = "<stdin>"
 )
 
 func main() {
-	var tagPrefix string
-	var useOmitZero bool
-	flag.StringVar(&tagPrefix, "tag-prefix", "json", "tag prefix to use, default is json")
-	flag.BoolVar(&useOmitZero, "use-omitzero", false, "use omitzero instead of omitempty for empty values")
+	opts := generator.DefaultOptions()
+	setTags := func(s string) error {
+		tags, cases, omitFlags, err := parseTags(s)
+		if err != nil {
+			return err
+		}
+		opts.Tags, opts.Cases, opts.OmitFlags = tags, cases, omitFlags
+		return nil
+	}
+	flag.Func("tags", "comma separated struct tag keys to write on each field, each optionally followed by :omitempty or :omitzero to select the flag of empty values and by =case to convert the YAML key to keep, snake, camel, kebab, pascal or screaming_snake, e.g. json:omitzero=camel,yaml; default is json", setTags)
+	flag.Func("tag-prefix", "single struct tag key to use, same as -tags with one key", setTags)
+	flag.BoolVar(&opts.OmitZero, "use-omitzero", opts.OmitZero, "use omitzero instead of omitempty for empty values, on the tags without a flag in -tags")
+	flag.Var(&opts.Pointers, "pointers", "which scalar fields are pointers: always, never or optional; default is always, optional with -merge-documents")
+	flag.Var(&opts.Naming, "naming", "strategy for colliding struct names: parent or merge")
+	flag.StringVar(&opts.Package, "package", opts.Package, "generate a complete, gofmt'd Go file in this package")
+	var outFile, outDir string
+	flag.StringVar(&outFile, "o", "", "write the generated Go file, or JSON Schema, to this file instead of stdout")
+	flag.StringVar(&outDir, "out-dir", "", "write the generated Go files to this directory instead of stdout")
+	flag.Var(&opts.Format, "format", "what to generate: go types or a jsonschema, draft 2020-12, validating the YAML; default is go")
+	flag.Var(&opts.Split, "split", "how -out-dir divides the code into files: none, document or struct")
+	flag.BoolVar(&opts.MergeDocuments, "merge-documents", opts.MergeDocuments, "merge every document as a sample of one schema: keys missing from some samples, or null, are optional and omitempty, the others required whatever their value; implies -pointers optional unless given")
+	flag.BoolVar(&opts.ValidateRequired, "validate-required", opts.ValidateRequired, "add a validate:\"required\" struct tag to the fields that are not optional, their scalars become pointers so that false, 0 and \"\" pass")
+	flag.BoolVar(&opts.Values, "emit-values", opts.Values, "generate after the types a variable for each document holding its data, e.g. var DocumentValue = Document{...}")
+	flag.BoolVar(&opts.Verify, "verify", opts.Verify, "type-check the generated code and report what doesn't compile at the YAML that produced it")
+	var roundTrip string
+	flag.StringVar(&roundTrip, "roundtrip", "", "decode the YAML into the generated types with the decoder of this tag key, json or yaml, encode it back and report the keys lost and values changed")
+	var check bool
+	flag.BoolVar(&check, "check", false, "compare the generated code with the files of -o or -out-dir instead of writing them, print a diff and fail when they differ or when -out-dir holds files generated from the same yaml arguments that would be removed")
+	var dumpSchema bool
+	flag.BoolVar(&dumpSchema, "dump-schema", false, "print the structure inferred from the YAML as JSON instead of the generated code, to debug the inference")
+	include := "*.yaml,*.yml"
+	flag.StringVar(&include, "include", include, "comma separated glob patterns selecting the files read from directory arguments")
 	flag.Parse()
 
 	if len(flag.Args()) < 1 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <options> [yaml-file]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s <options> [yaml-file|directory|glob|-]...\n", os.Args[0])
 		os.Exit(1)
 	}
 
-	filename := flag.Arg(0)
-	data, err := os.ReadFile(filename)
+	if outFile != "" && outDir != "" {
+		fmt.Fprintln(os.Stderr, "Error: -o and -out-dir can't be used together")
+		os.Exit(1)
+	}
+	if dumpSchema && (outFile != "" || outDir != "" || check) {
+		fmt.Fprintln(os.Stderr, "Error: -dump-schema can't be used with -o, -out-dir or -check")
+		os.Exit(1)
+	}
+	if opts.Format == generator.FormatJSONSchema && (outDir != "" || roundTrip != "") {
+		fmt.Fprintln(os.Stderr, "Error: -format jsonschema can't be used with -out-dir or -roundtrip")
+		os.Exit(1)
+	}
+	if opts.Split != "" && outDir == "" {
+		fmt.Fprintln(os.Stderr, "Error: -split requires -out-dir")
+		os.Exit(1)
+	}
+
+	filenames, err := inputFiles(flag.Args(), splitList(include))
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Parse YAML into AST
-	file, err := parser.ParseBytes(data, parser.ParseComments)
+	// Every file is parsed before generating, the documents of all of them
+	// make a single set of types
+	files := make([]*ast.File, 0, len(filenames))
+	inputs := make([]roundtrip.Input, 0, len(filenames))
+	for _, filename := range filenames {
+		file, data, err := parseFile(filename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing YAML: %v\n", err)
+			os.Exit(1)
+		}
+		files = append(files, file)
+		inputs = append(inputs, roundtrip.Input{Name: file.Name, Data: data, File: file})
+	}
+
+	opts.Warn = printDiagnostic
+	opts.Source = source(flag.Args())
+
+	if dumpSchema {
+		s, err := generator.New(opts).Schema(files...)
+		if err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+		data, err := s.JSON()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(data)
+		return
+	}
+
+	if roundTrip != "" {
+		if !checkRoundTrip(opts, roundTrip, files, inputs) {
+			os.Exit(1)
+		}
+	}
+
+	if outFile == "" && outDir == "" {
+		if check {
+			fmt.Fprintln(os.Stderr, "Error: -check requires -o or -out-dir")
+			os.Exit(1)
+		}
+
+		source, err := generator.New(opts).Generate(files...)
+		if err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+		fmt.Print(source)
+		return
+	}
+
+	// Written files are complete Go files, in the package of their
+	// directory unless given
+	if opts.Package == "" && opts.Format != generator.FormatJSONSchema {
+		name, err := output.PackageName(outputDir(outFile, outDir))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Package = name
+	}
+
+	generated, err := generateOutputs(generator.New(opts), files, outFile, outDir)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing YAML: %v\n", err)
+		printError(err)
 		os.Exit(1)
 	}
 
-	gen := generator.New()
-	fmt.Print(gen.Generate(file, tagPrefix, useOmitZero))
+	// Files of -out-dir generated before but no longer produced, such as
+	// the file of a struct that was removed, would stay in the package
+	var stale []string
+	if outDir != "" {
+		stale, err = staleFiles(outDir, opts.Source, generated)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", outDir, err)
+			os.Exit(1)
+		}
+	}
+
+	if check {
+		if !checkOutputs(generated, stale) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := os.MkdirAll(outputDir(outFile, outDir), 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+		os.Exit(1)
+	}
+	for _, f := range generated {
+		if _, err := output.WriteFile(f.path, f.source); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	for _, name := range stale {
+		if err := os.Remove(name); err != nil {
+			fmt.Fprintf(os.Stderr, "Error removing file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// staleFiles returns the files of dir written by a previous run of yaml2go
+// from the same source, marked by the generated code header naming it, that
+// are not in generated. The files generated from other YAML, such as the
+// ones of another go:generate line, are kept.
+func staleFiles(dir, source string, generated []outputFile) ([]string, error) {
+	previous, err := output.GeneratedFiles(dir, generator.FilePattern, codegen.Header(source))
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []string
+	for _, name := range previous {
+		if !slices.ContainsFunc(generated, func(f outputFile) bool {
+			return filepath.Clean(f.path) == filepath.Clean(name)
+		}) {
+			stale = append(stale, name)
+		}
+	}
+
+	return stale, nil
+}
+
+// source returns the arguments naming the YAML input, as the generated code
+// header names them.
+func source(args []string) string {
+	names := make([]string, len(args))
+	for i, arg := range args {
+		names[i] = arg
+		if arg == stdinArg {
+			names[i] = stdinName
+		}
+	}
+
+	return strings.Join(names, " ")
+}
+
+// outputDir returns the directory the generated files are written to.
+func outputDir(outFile, outDir string) string {
+	if outFile != "" {
+		return filepath.Dir(outFile)
+	}
+
+	return outDir
+}
+
+// outputFile is a generated file and the path it is written to.
+type outputFile struct {
+	path   string
+	source []byte
+}
+
+// generateOutputs returns the files generated for -o or -out-dir.
+func generateOutputs(gen *generator.Generator, files []*ast.File, outFile, outDir string) ([]outputFile, error) {
+	if outFile != "" {
+		source, err := gen.Generate(files...)
+		if err != nil {
+			return nil, err
+		}
+		return []outputFile{{path: outFile, source: []byte(source)}}, nil
+	}
+
+	generated, err := gen.GenerateFiles(files...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]outputFile, 0, len(generated))
+	for _, f := range generated {
+		result = append(result, outputFile{path: filepath.Join(outDir, f.Name), source: f.Source})
+	}
+
+	return result, nil
+}
+
+// checkOutputs prints the diff of every generated file that differs from
+// the file on disk, and of every stale file to remove, and reports whether
+// they all match.
+func checkOutputs(generated []outputFile, stale []string) bool {
+	upToDate := true
+	for _, f := range generated {
+		d, err := output.Check(f.path, f.source)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error checking file: %v\n", err)
+			os.Exit(1)
+		}
+		if d != "" {
+			fmt.Print(d)
+			fmt.Fprintf(os.Stderr, "%s: out of date, regenerate it with yaml2go\n", f.path)
+			upToDate = false
+		}
+	}
+	for _, name := range stale {
+		d, err := output.CheckRemoved(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error checking file: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(d)
+		fmt.Fprintf(os.Stderr, "%s: no longer generated, regenerate with yaml2go to remove it\n", name)
+		upToDate = false
+	}
+
+	return upToDate
+}
+
+// inputFiles returns the files named by args in order. A directory stands
+// for the files below it whose name matches one of patterns, a glob for the
+// files it matches and - for stdin.
+func inputFiles(args []string, patterns []string) ([]string, error) {
+	var filenames []string
+	for _, arg := range args {
+		if arg == stdinArg {
+			filenames = append(filenames, arg)
+			continue
+		}
+
+		info, err := os.Stat(arg)
+		switch {
+		case err == nil && info.IsDir():
+			matches, err := walkDir(arg, patterns)
+			if err != nil {
+				return nil, err
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files matching %s in %s", strings.Join(patterns, ","), arg)
+			}
+			filenames = append(filenames, matches...)
+
+		case err == nil:
+			filenames = append(filenames, arg)
+
+		case errors.Is(err, fs.ErrNotExist) && strings.ContainsAny(arg, "*?["):
+			// A pattern left unexpanded by the shell, e.g. when quoted
+			matches, err := filepath.Glob(arg)
+			if err != nil {
+				return nil, err
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files matching %s", arg)
+			}
+			filenames = append(filenames, matches...)
+
+		default:
+			return nil, err
+		}
+	}
+
+	return filenames, nil
+}
+
+// walkDir returns the files below dir whose name matches one of patterns,
+// in lexical order.
+func walkDir(dir string, patterns []string) ([]string, error) {
+	var matches []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		for _, pattern := range patterns {
+			matched, err := filepath.Match(pattern, d.Name())
+			if err != nil {
+				return err
+			}
+			if matched {
+				matches = append(matches, path)
+				break
+			}
+		}
+		return nil
+	})
+
+	return matches, err
+}
+
+// parseFile parses the YAML of filename, or of stdin for -, keeping the
+// comments, and returns it with the YAML. The file is named after filename
+// for the diagnostics.
+func parseFile(filename string) (*ast.File, []byte, error) {
+	var data []byte
+	var err error
+	if filename == stdinArg {
+		filename = stdinName
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := parser.ParseBytes(data, parser.ParseComments)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	file.Name = filename
+
+	return file, data, nil
+}
+
+// checkRoundTrip decodes inputs into the types generated for files with the
+// decoder of the format tag key and reports whether they fit. The problems
+// are printed, the warnings are left to the generation of the output.
+func checkRoundTrip(opts generator.Options, format string, files []*ast.File, inputs []roundtrip.Input) bool {
+	if !slices.Contains(roundtrip.Formats, format) {
+		fmt.Fprintf(os.Stderr, "Error: -roundtrip %s: no decoder for this struct tag key, expected one of %s\n", format, strings.Join(roundtrip.Formats, ", "))
+		return false
+	}
+	if !slices.Contains(opts.Tags, format) {
+		fmt.Fprintf(os.Stderr, "Error: -roundtrip %s requires the %s struct tag key in -tags\n", format, format)
+		return false
+	}
+
+	opts.Warn, opts.Values = nil, false
+	if opts.Package == "" {
+		opts.Package = "types"
+	}
+	gen := generator.New(opts)
+
+	source, err := gen.Generate(files...)
+	if err != nil {
+		printError(err)
+		return false
+	}
+
+	roots := gen.Roots(files...)
+	for i := range inputs {
+		inputs[i].Roots = roots[i]
+	}
+
+	diags, err := roundtrip.Check([]byte(source), format, inputs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return false
+	}
+	for _, d := range diags {
+		printDiagnostic(d)
+	}
+
+	return len(diags) == 0
+}
+
+// splitList returns the non empty elements of a comma separated list.
+func splitList(s string) []string {
+	var elements []string
+	for _, element := range strings.Split(s, ",") {
+		if element = strings.TrimSpace(element); element != "" {
+			elements = append(elements, element)
+		}
+	}
+
+	return elements
+}
+
+// parseTags returns the tag keys of a comma separated list, the case of the
+// keys written as key=case and the omit flag of the keys written as
+// key:flag, or key:flag=case. An empty list generates no tags.
+func parseTags(s string) ([]string, map[string]ident.Case, map[string]string, error) {
+	var tags []string
+	cases := make(map[string]ident.Case)
+	omitFlags := make(map[string]string)
+	for _, tag := range splitList(s) {
+		tag, name, hasCase := strings.Cut(tag, "=")
+		tag, flag, hasFlag := strings.Cut(tag, ":")
+		if tag = strings.TrimSpace(tag); tag == "" {
+			continue
+		}
+		tags = append(tags, tag)
+
+		if hasFlag {
+			f, err := golang.ParseOmitFlag(strings.TrimSpace(flag))
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			omitFlags[tag] = f
+		}
+		if hasCase {
+			c, err := ident.ParseCase(strings.TrimSpace(name))
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			cases[tag] = c
+		}
+	}
+
+	return tags, cases, omitFlags, nil
+}
+
+// printError prints err to stderr, one line per diagnostic.
+func printError(err error) {
+	var diags diag.List
+	if !errors.As(err, &diags) {
+		fmt.Fprintf(os.Stderr, "Error generating code: %v\n", err)
+		return
+	}
+
+	for _, d := range diags {
+		printDiagnostic(d)
+	}
+}
+
+// printDiagnostic prints d to stderr, prefixed with the name of its YAML
+// file.
+func printDiagnostic(d diag.Diagnostic) {
+	fmt.Fprintln(os.Stderr, d)
 }
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"encoding/json"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/richerve/yaml2go/pkg/jsonschema"
+	"github.com/richerve/yaml2go/pkg/schema"
 )
 
 func TestMain_Integration(t *testing.T) {
@@ -34,6 +39,64 @@ user:
 			args:        []string{"-tag-prefix", "yaml", "test.yaml"},
 			expectError: false,
 		},
+		{
+			name: "YAML with multiple tags",
+			yamlContent: `
+name: "jane"
+`,
+			args:        []string{"-tags", "json,yaml,mapstructure", "test.yaml"},
+			expectError: false,
+		},
+		{
+			name: "YAML with tag cases",
+			yamlContent: `
+max_connections: 10
+`,
+			args:        []string{"-tags", "json=camel,yaml=keep", "test.yaml"},
+			expectError: false,
+		},
+		{
+			name: "YAML with tag omit flags",
+			yamlContent: `
+max_connections: 0
+`,
+			args:        []string{"-tags", "json:omitzero=camel,yaml", "test.yaml"},
+			expectError: false,
+		},
+		{
+			name: "verified output",
+			yamlContent: `
+started: !!timestamp 2024-01-02
+server:
+  ports: [80, 443]
+`,
+			args:        []string{"-verify", "test.yaml"},
+			expectError: false,
+		},
+		{
+			name: "values of the documents",
+			yamlContent: `
+started: !!timestamp 2024-01-02
+servers:
+  - host: a
+    port: 80
+  - host: b
+`,
+			args:        []string{"-emit-values", "-pointers", "optional", "-package", "config", "-verify", "test.yaml"},
+			expectError: false,
+		},
+		{
+			name: "documents merged as samples",
+			yamlContent: `
+name: app
+port: 80
+---
+name: api
+replicas: 2
+`,
+			args:        []string{"-merge-documents", "-validate-required", "-verify", "test.yaml"},
+			expectError: false,
+		},
 		{
 			name: "single key YAML",
 			yamlContent: `
@@ -42,6 +105,15 @@ key: "value"
 			args:        []string{"test.yaml"},
 			expectError: false,
 		},
+		{
+			name: "complete file with package clause",
+			yamlContent: `
+user:
+  name: "jane"
+`,
+			args:        []string{"-package", "config", "test.yaml"},
+			expectError: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -116,6 +188,124 @@ func TestMain_ErrorCases(t *testing.T) {
 			args:        []string{"nonexistent.yaml"},
 			expectError: true,
 		},
+		{
+			name:        "invalid package name",
+			args:        []string{"-package", "my-config", "invalid.yaml"},
+			yamlContent: "name: test",
+			expectError: true,
+		},
+		{
+			name:        "unknown format",
+			args:        []string{"-format", "proto", "invalid.yaml"},
+			yamlContent: "name: test",
+			expectError: true,
+		},
+		{
+			name:        "JSON Schema with out-dir",
+			args:        []string{"-format", "jsonschema", "-out-dir", "generated", "invalid.yaml"},
+			yamlContent: "name: test",
+			expectError: true,
+		},
+		{
+			name:        "JSON Schema with values",
+			args:        []string{"-format", "jsonschema", "-emit-values", "invalid.yaml"},
+			yamlContent: "name: test",
+			expectError: true,
+		},
+		{
+			name:        "unknown naming strategy",
+			args:        []string{"-naming", "last", "invalid.yaml"},
+			yamlContent: "name: test",
+			expectError: true,
+		},
+		{
+			name:        "invalid tag key",
+			args:        []string{"-tags", "json,my:tag", "invalid.yaml"},
+			yamlContent: "name: test",
+			expectError: true,
+		},
+		{
+			name:        "unknown tag omit flag",
+			args:        []string{"-tags", "json:omitnil", "invalid.yaml"},
+			yamlContent: "name: test",
+			expectError: true,
+		},
+		{
+			name:        "unknown tag case",
+			args:        []string{"-tags", "json=title", "invalid.yaml"},
+			yamlContent: "name: test",
+			expectError: true,
+		},
+		{
+			name:        "schema dump written to a file",
+			args:        []string{"-dump-schema", "-o", "types.go", "invalid.yaml"},
+			yamlContent: "name: test",
+			expectError: true,
+		},
+		{
+			name:        "validate tag along with the required fields",
+			args:        []string{"-validate-required", "-tags", "json,validate", "invalid.yaml"},
+			yamlContent: "name: test",
+			expectError: true,
+		},
+		{
+			name:        "unknown pointer policy",
+			args:        []string{"-pointers", "sometimes", "invalid.yaml"},
+			yamlContent: "name: test",
+			expectError: true,
+		},
+		{
+			name:        "alias to an undefined anchor",
+			args:        []string{"invalid.yaml"},
+			yamlContent: "url: *missing",
+			expectError: true,
+		},
+		{
+			name:        "directory without YAML files",
+			args:        []string{"-include", "*.json", "."},
+			expectError: true,
+		},
+		{
+			name:        "both output file and directory",
+			args:        []string{"-o", "out.go", "-out-dir", "out", "invalid.yaml"},
+			yamlContent: "name: test",
+			expectError: true,
+		},
+		{
+			name:        "split without output directory",
+			args:        []string{"-split", "struct", "invalid.yaml"},
+			yamlContent: "name: test",
+			expectError: true,
+		},
+		{
+			name:        "unknown split",
+			args:        []string{"-out-dir", "out", "-split", "file", "invalid.yaml"},
+			yamlContent: "name: test",
+			expectError: true,
+		},
+		{
+			name:        "check without output",
+			args:        []string{"-check", "invalid.yaml"},
+			yamlContent: "name: test",
+			expectError: true,
+		},
+		{
+			name:        "round trip without its tag",
+			args:        []string{"-tags", "yaml", "-roundtrip", "json", "invalid.yaml"},
+			yamlContent: "name: test",
+			expectError: true,
+		},
+		{
+			name:        "round trip without decoder",
+			args:        []string{"-tags", "toml", "-roundtrip", "toml", "invalid.yaml"},
+			yamlContent: "name: test",
+			expectError: true,
+		},
+		{
+			name:        "glob without matches",
+			args:        []string{"missing/*.yaml"},
+			expectError: true,
+		},
 		{
 			name:        "invalid YAML syntax",
 			args:        []string{"invalid.yaml"},
@@ -169,3 +359,386 @@ func TestMain_ErrorCases(t *testing.T) {
 		})
 	}
 }
+
+func TestMain_MultipleInputs(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"dev.yaml":         "name: app\nport: 80\n",
+		"staging/app.yml":  "name: app\ndebug: true\n",
+		"notes.txt":        "ignored: true\n",
+		"prod/values.yaml": "name: app\nreplicas: 3\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		expected []string
+		excluded []string
+	}{
+		{
+			name:     "stdin",
+			args:     []string{"-"},
+			stdin:    "region: eu\n",
+			expected: []string{"Region"},
+		},
+		{
+			name:     "files and stdin",
+			args:     []string{filepath.Join(dir, "dev.yaml"), "-"},
+			stdin:    "name: app\nregion: eu\n",
+			expected: []string{"Name", "Port", "Region"},
+		},
+		{
+			name:     "directory",
+			args:     []string{dir},
+			expected: []string{"Name", "Port", "Debug", "Replicas"},
+			excluded: []string{"Ignored"},
+		},
+		{
+			name:     "directory with include patterns",
+			args:     []string{"-include", "*.txt", dir},
+			expected: []string{"Ignored"},
+			excluded: []string{"Name"},
+		},
+		{
+			name:     "glob",
+			args:     []string{filepath.Join(dir, "*", "*.y*ml")},
+			expected: []string{"Debug", "Replicas"},
+			excluded: []string{"Port"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command("go", append([]string{"run", "main.go"}, tt.args...)...)
+			cmd.Dir = "."
+			cmd.Stdin = strings.NewReader(tt.stdin)
+
+			output, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v\nOutput: %s", err, string(output))
+			}
+
+			// Every input is merged into a single root struct
+			outputStr := string(output)
+			if strings.Count(outputStr, "type ") != 1 {
+				t.Errorf("Expected a single struct, got:\n%s", outputStr)
+			}
+			for _, field := range tt.expected {
+				if !strings.Contains(outputStr, "\t"+field+" ") {
+					t.Errorf("Expected field %s in output:\n%s", field, outputStr)
+				}
+			}
+			for _, field := range tt.excluded {
+				if strings.Contains(outputStr, "\t"+field+" ") {
+					t.Errorf("Unexpected field %s in output:\n%s", field, outputStr)
+				}
+			}
+		})
+	}
+}
+
+func TestMain_Output(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(input, []byte("server:\n  host: localhost\n---\ndb:\n  url: postgres://\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected map[string]string
+	}{
+		{
+			name:     "single file",
+			args:     []string{"-o", filepath.Join(dir, "gen", "config.go")},
+			expected: map[string]string{"gen/config.go": "package gen\n"},
+		},
+		{
+			name: "directory split by document",
+			args: []string{"-out-dir", filepath.Join(dir, "settings"), "-split", "document", "-package", "cfg"},
+			expected: map[string]string{
+				"settings/server_gen.go": "package cfg\n",
+				"settings/db_gen.go":     "package cfg\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"run", "main.go"}, tt.args...)
+			cmd := exec.Command("go", append(args, input)...)
+			cmd.Dir = "."
+
+			output, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v\nOutput: %s", err, string(output))
+			}
+			if len(output) != 0 {
+				t.Errorf("Expected no output, got: %s", output)
+			}
+
+			for name, pkg := range tt.expected {
+				data, err := os.ReadFile(filepath.Join(dir, name))
+				if err != nil {
+					t.Fatalf("Expected file %s: %v", name, err)
+				}
+				if !strings.Contains(string(data), pkg) {
+					t.Errorf("Expected %s to contain %q, got:\n%s", name, pkg, data)
+				}
+			}
+		})
+	}
+}
+
+func TestMain_Check(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "config.yaml")
+	outFile := filepath.Join(dir, "config.go")
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command("go", append([]string{"run", "main.go", "-package", "config"}, args...)...)
+		cmd.Dir = "."
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	if err := os.WriteFile(input, []byte("name: app\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if output, err := run("-check", "-o", outFile, input); err == nil {
+		t.Errorf("Expected the missing file to fail the check, got: %s", output)
+	}
+	if _, err := os.Stat(outFile); err == nil {
+		t.Errorf("Expected -check not to write %s", outFile)
+	}
+
+	if output, err := run("-o", outFile, input); err != nil {
+		t.Fatalf("Unexpected error: %v\nOutput: %s", err, output)
+	}
+	if output, err := run("-check", "-o", outFile, input); err != nil {
+		t.Errorf("Expected the check to pass: %v\nOutput: %s", err, output)
+	}
+
+	// A new key makes the file out of date
+	if err := os.WriteFile(input, []byte("name: app\nport: 80\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	output, err := run("-check", "-o", outFile, input)
+	if err == nil {
+		t.Errorf("Expected the check to fail")
+	}
+	if !strings.Contains(output, "+\tPort") || !strings.Contains(output, "out of date") {
+		t.Errorf("Expected a diff adding Port, got: %s", output)
+	}
+}
+
+func TestMain_StaleFiles(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "config.yaml")
+	other := filepath.Join(dir, "other.yaml")
+	outDir := filepath.Join(dir, "config")
+	if err := os.WriteFile(input, []byte("server:\n  host: localhost\ndb:\n  url: postgres://\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.WriteFile(other, []byte("cache:\n  size: 10\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command("go", append([]string{"run", "main.go", "-package", "config"}, args...)...)
+		cmd.Dir = "."
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+	files := func() string {
+		t.Helper()
+		entries, err := os.ReadDir(outDir)
+		if err != nil {
+			t.Fatalf("Failed to read directory: %v", err)
+		}
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return strings.Join(names, ",")
+	}
+
+	if output, err := run("-out-dir", outDir, "-split", "struct", input); err != nil {
+		t.Fatalf("Unexpected error: %v\nOutput: %s", err, output)
+	}
+	// Files of the package not generated from config.yaml: written by hand
+	// and by another go:generate line
+	if err := os.WriteFile(filepath.Join(outDir, "hand_gen.go"), []byte("package config\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if output, err := run("-o", filepath.Join(outDir, "other_gen.go"), other); err != nil {
+		t.Fatalf("Unexpected error: %v\nOutput: %s", err, output)
+	}
+	if names := files(); names != "db_gen.go,document_gen.go,hand_gen.go,other_gen.go,server_gen.go" {
+		t.Fatalf("Files = %s, want one per struct", names)
+	}
+
+	// Checking the new split reports the struct files to remove, without
+	// removing them
+	output, err := run("-out-dir", outDir, "-split", "document", "-check", input)
+	if err == nil {
+		t.Errorf("Expected the stale files to fail the check")
+	}
+	for _, expected := range []string{
+		"--- " + filepath.Join(outDir, "server_gen.go") + "\n+++ " + os.DevNull,
+		filepath.Join(outDir, "db_gen.go") + ": no longer generated",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in output: %s", expected, output)
+		}
+	}
+	if strings.Contains(output, "hand_gen.go") || strings.Contains(output, "other_gen.go") {
+		t.Errorf("Unexpected file of another source in output: %s", output)
+	}
+	if names := files(); names != "db_gen.go,document_gen.go,hand_gen.go,other_gen.go,server_gen.go" {
+		t.Errorf("Files = %s, want -check to remove none", names)
+	}
+
+	// The files of the structs are generated no more, the others stay
+	if output, err := run("-out-dir", outDir, "-split", "document", input); err != nil {
+		t.Fatalf("Unexpected error: %v\nOutput: %s", err, output)
+	}
+	if names := files(); names != "document_gen.go,hand_gen.go,other_gen.go" {
+		t.Errorf("Files = %s, want the stale struct files removed", names)
+	}
+	if output, err := run("-out-dir", outDir, "-split", "document", "-check", input); err != nil {
+		t.Errorf("Expected the check to pass: %v\nOutput: %s", err, output)
+	}
+}
+
+func TestMain_RoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the go command")
+	}
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(input, []byte("server_name: app\nlimits:\n  max_conns: 3\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command("go", append([]string{"run", "main.go"}, args...)...)
+		cmd.Dir = "."
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	for _, format := range []string{"json", "yaml"} {
+		if output, err := run("-tags", "json,yaml", "-roundtrip", format, input); err != nil {
+			t.Errorf("Expected the %s round trip to pass: %v\nOutput: %s", format, err, output)
+		}
+	}
+
+	// Camel case json tags don't decode the snake case keys
+	output, err := run("-tags", "json=camel", "-roundtrip", "json", input)
+	if err == nil {
+		t.Errorf("Expected the round trip to fail")
+	}
+	for _, expected := range []string{
+		"config.yaml:1:1: Document.server_name: round trip: key lost",
+		"config.yaml:3:3: Document.limits.max_conns: round trip: key lost",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in output: %s", expected, output)
+		}
+	}
+}
+
+func TestMain_DumpSchema(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(input, []byte("name: app\nports: [80, 443]\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	cmd := exec.Command("go", "run", "main.go", "-dump-schema", input)
+	cmd.Dir = "."
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var s schema.Schema
+	if err := json.Unmarshal(output, &s); err != nil {
+		t.Fatalf("Expected the schema as JSON: %v\nOutput: %s", err, output)
+	}
+	if len(s.Objects) != 1 || len(s.Objects[0].Fields) != 2 {
+		t.Fatalf("Expected one object of two fields, got %+v", s.Objects)
+	}
+	if ports := s.Objects[0].Fields[1]; ports.Type.String() != "[]integer" {
+		t.Errorf("Type of ports = %v, want []integer", ports.Type)
+	}
+}
+
+func TestMain_JSONSchema(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "config.yaml")
+	outFile := filepath.Join(dir, "config.schema.json")
+	if err := os.WriteFile(input, []byte("# Name of the app\nname: app\nports: [80, 443]\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command("go", append([]string{"run", "main.go", "-format", "jsonschema"}, args...)...)
+		cmd.Dir = "."
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	output, err := run(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\nOutput: %s", err, output)
+	}
+	var doc struct {
+		Schema string `json:"$schema"`
+		Ref    string `json:"$ref"`
+		Defs   map[string]struct {
+			Properties map[string]struct {
+				Description string `json:"description"`
+				Type        string `json:"type"`
+			} `json:"properties"`
+			Required []string `json:"required"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
+		t.Fatalf("Expected a JSON Schema: %v\nOutput: %s", err, output)
+	}
+	if doc.Schema != jsonschema.Draft || doc.Ref != "#/$defs/Document" {
+		t.Errorf("Expected a draft 2020-12 schema of Document, got $schema %q and $ref %q", doc.Schema, doc.Ref)
+	}
+	def := doc.Defs["Document"]
+	if name := def.Properties["name"]; name.Type != "string" || name.Description != "Name of the app" {
+		t.Errorf("Expected name to be a described string, got %+v", name)
+	}
+	if strings.Join(def.Required, ",") != "name,ports" {
+		t.Errorf("Required = %v, want [name ports]", def.Required)
+	}
+
+	// The schema is written as is, outside of any Go package
+	if output, err := run("-o", outFile, input); err != nil {
+		t.Fatalf("Unexpected error: %v\nOutput: %s", err, output)
+	}
+	if output, err := run("-check", "-o", outFile, input); err != nil {
+		t.Errorf("Expected the check to pass: %v\nOutput: %s", err, output)
+	}
+}
@@ -2,18 +2,155 @@ package codegen
 
 import (
 	"fmt"
+	"go/format"
+	"sort"
 	"strings"
+	"unicode"
+
+	"github.com/richerve/yaml2go/pkg/ident"
+	"github.com/richerve/yaml2go/pkg/schema"
 )
 
+// GeneratedHeader marks files written by yaml2go as generated code, see
+// https://go.dev/s/generatedcode.
+const GeneratedHeader = "// Code generated by yaml2go. DO NOT EDIT."
+
+// Header returns the generated code header of the files generated from
+// source, such as the arguments of yaml2go, or GeneratedHeader when source
+// is empty. Files generated from different sources have different headers.
+func Header(source string) string {
+	if source == "" {
+		return GeneratedHeader
+	}
+
+	return "// Code generated by yaml2go from " + source + ". DO NOT EDIT."
+}
+
+// File is a complete Go source file holding the generated structs and the
+// variables holding values of them.
+type File struct {
+	Package string
+	// Source names what the file is generated from in its header, see
+	// Header.
+	Source  string
+	Structs []StructDef
+	Values  []ValueDef
+}
+
+func (f File) String() string {
+	var builder strings.Builder
+	builder.WriteString(Header(f.Source))
+	builder.WriteString("\n\n")
+	fmt.Fprintf(&builder, "package %s\n", f.Package)
+
+	imports := f.Imports()
+	if len(imports) > 0 {
+		builder.WriteString("\nimport (\n")
+		for _, path := range imports {
+			fmt.Fprintf(&builder, "\t%q\n", path)
+		}
+		builder.WriteString(")\n")
+	}
+
+	builder.WriteString("\n")
+	builder.WriteString(f.Declarations())
+
+	return builder.String()
+}
+
+// Declarations returns the structs followed by the values of the file and
+// the helpers they use, without the package clause and the imports.
+func (f File) Declarations() string {
+	var decls []string
+	for _, s := range f.Structs {
+		decls = append(decls, s.String())
+	}
+	pointers := false
+	for _, v := range f.Values {
+		decls = append(decls, v.String())
+		pointers = pointers || v.Pointers
+	}
+	if pointers {
+		decls = append(decls, pointerHelper)
+	}
+
+	return strings.Join(decls, "\n")
+}
+
+// Format returns the file source formatted with gofmt.
+func (f File) Format() ([]byte, error) {
+	source, err := format.Source([]byte(f.String()))
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+
+	return source, nil
+}
+
+// Imports returns the sorted import paths of the packages used by the field
+// types and the values of the file.
+func (f File) Imports() []string {
+	seen := make(map[string]bool)
+	for _, s := range f.Structs {
+		for _, field := range s.Fields {
+			if path := typeImport(field.Schema); path != "" {
+				seen[path] = true
+			}
+		}
+	}
+	for _, v := range f.Values {
+		for _, path := range v.Imports {
+			seen[path] = true
+		}
+	}
+
+	imports := make([]string, 0, len(seen))
+	for path := range seen {
+		imports = append(imports, path)
+	}
+	sort.Strings(imports)
+
+	return imports
+}
+
+// typeImport returns the import path of the package used by the Go type of
+// t, or "" when it uses none.
+func typeImport(t schema.Type) string {
+	switch t.Kind {
+	case schema.KindTimestamp:
+		return "time"
+	case schema.KindArray:
+		return typeImport(t.Element())
+	default:
+		return ""
+	}
+}
+
 type StructDef struct {
 	Name   string
 	Fields []FieldDef
+	// Comment is written as the doc comment of the type, one line per line
+	// of the comment.
+	Comment string
+	// Source is the first mapping the struct was generated from.
+	Source Source
+}
+
+// Source locates the YAML that produced a struct or a field, to report the
+// problems found in the generated code.
+type Source struct {
+	File   string
+	Path   string
+	Line   int
+	Column int
 }
 
 func (s StructDef) String() string {
 	var builder strings.Builder
+	writeComment(&builder, "", s.Comment)
 	fmt.Fprintf(&builder, "type %s struct {\n", s.Name)
 	for _, field := range s.Fields {
+		writeComment(&builder, "\t", field.Comment)
 		builder.WriteString("\t")
 		fmt.Fprint(&builder, field.String())
 		builder.WriteString("\n")
@@ -24,64 +161,178 @@ func (s StructDef) String() string {
 }
 
 type FieldDef struct {
-	Name string
-	Type string
-	Tag  *FieldTag
+	// Name is the YAML key, GoName the field name when it differs from the
+	// one derived from the key, e.g. to tell apart keys producing the same
+	// name.
+	Name   string
+	GoName string
+	Type   string
+	// Schema is the type of the values Type is written from, Pointer
+	// reports whether Type is a pointer to them.
+	Schema  schema.Type
+	Pointer bool
+	// Tags are written in order as the struct tag of the field.
+	Tags []FieldTag
+	// Comment is written as the doc comment of the field.
+	Comment string
+	// Source is the first key the field was generated from.
+	Source Source
 }
 
 func (f FieldDef) String() string {
 	var builder strings.Builder
 
-	builder.WriteString(fmt.Sprintf("%s %s", Capitalize(f.Name), f.Type))
-	if f.Tag != nil && f.Tag.String() != "" {
-		builder.WriteString(fmt.Sprintf(" %s", f.Tag.String()))
+	builder.WriteString(fmt.Sprintf("%s %s", f.FieldName(), f.Type))
+
+	var tags []string
+	for _, tag := range f.Tags {
+		if tag.String() != "" {
+			tags = append(tags, tag.String())
+		}
+	}
+	if len(tags) > 0 {
+		builder.WriteString(fmt.Sprintf(" `%s`", strings.Join(tags, " ")))
 	}
 	return builder.String()
 }
 
+// FieldName returns the Go name of the field.
+func (f FieldDef) FieldName() string {
+	if f.GoName != "" {
+		return f.GoName
+	}
+
+	return ident.Exported(f.Name)
+}
+
+// ValueDef is a package level variable holding a value of the generated
+// types, such as the data of a YAML document.
+type ValueDef struct {
+	Name string
+	// Value is the Go expression the variable is initialized with, a
+	// composite literal giving its type.
+	Value string
+	// Imports are the paths of the packages used by Value, which can't be
+	// told from the expression as it holds string literals.
+	Imports []string
+	// Pointers is set when Value takes the address of scalars with the ptr
+	// helper, declared once with the values of the file.
+	Pointers bool
+	// Comment is written as the doc comment of the variable.
+	Comment string
+	// Source is the YAML the value was generated from.
+	Source Source
+}
+
+func (v ValueDef) String() string {
+	var builder strings.Builder
+	writeComment(&builder, "", v.Comment)
+	fmt.Fprintf(&builder, "var %s = %s\n", v.Name, v.Value)
+
+	return builder.String()
+}
+
+// PointerHelper is the function of the generated code taking the address of
+// a scalar value, e.g. ptr("name") for a *string field.
+const PointerHelper = "ptr"
+
+const pointerHelper = `// ` + PointerHelper + ` returns a pointer to v, for the pointer fields of the values.
+func ` + PointerHelper + `[T any](v T) *T {
+	return &v
+}
+`
+
+// writeComment writes comment as // lines prefixed with indent.
+func writeComment(builder *strings.Builder, indent, comment string) {
+	if comment == "" {
+		return
+	}
+
+	for _, line := range strings.Split(comment, "\n") {
+		builder.WriteString(indent)
+		if line == "" {
+			builder.WriteString("//\n")
+			continue
+		}
+		fmt.Fprintf(builder, "// %s\n", line)
+	}
+}
+
+// FieldTag is one key:"value" pair of a struct tag, e.g. json:"name,omitempty"
+// for the Prefix json.
 type FieldTag struct {
 	Prefix string
 	Value  string
 	Flags  []string
 }
 
-func (f *FieldTag) String() string {
+func (f FieldTag) String() string {
 	var sb strings.Builder
 
 	if f.Prefix == "" || f.Value == "" {
 		return ""
 	}
 
-	fmt.Fprintf(&sb, "`%s:\"%s", f.Prefix, f.Value)
+	fmt.Fprintf(&sb, "%s:\"%s", f.Prefix, f.Value)
 	if len(f.Flags) > 0 {
 		for _, f := range f.Flags {
 			fmt.Fprintf(&sb, ",%s", f)
 		}
+	} else if f.Value == "-" {
+		// A lone - skips the field, the key - is written -,
+		sb.WriteString(",")
 	}
-	sb.WriteString("\"`")
+	sb.WriteString("\"")
 
 	return sb.String()
 }
 
-func Capitalize(s string) string {
+// ValidTagValue reports whether s can be the name written in a struct tag.
+// The tag is a raw string of quoted values, split on commas by the
+// decoders, so s can't be empty nor hold a comma, a quote, a backtick, a
+// backslash or a control character.
+func ValidTagValue(s string) bool {
 	if s == "" {
-		return ""
+		return false
 	}
 
-	// Convert snake_case or kebab-case to PascalCase
-	parts := strings.FieldsFunc(s, func(r rune) bool {
-		return r == '_' || r == '-'
-	})
+	for _, r := range s {
+		if strings.ContainsRune(",\"`\\", r) || unicode.IsControl(r) {
+			return false
+		}
+	}
 
-	var result strings.Builder
-	for _, part := range parts {
-		if len(part) > 0 {
-			result.WriteString(strings.ToUpper(string(part[0])))
-			if len(part) > 1 {
-				result.WriteString(part[1:])
-			}
+	return true
+}
+
+// ElementName returns the key used to name the elements of a sequence stored
+// under key s: plural keys are singularized ("items" becomes "item") and any
+// other key gets an "_item" suffix so the element never shares its name.
+// Singular words ending in s, such as status, address or analysis, are not
+// plurals. The empty key gives "_item".
+func ElementName(s string) string {
+	lower := strings.ToLower(s)
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(s) > 3:
+		return s[:len(s)-3] + "y"
+	case hasSuffix(lower, "sses", "uses", "iases", "xes", "zzes", "ches", "shes"):
+		return s[:len(s)-2]
+	case hasSuffix(lower, "ss", "us", "is"):
+		return s + "_item"
+	case strings.HasSuffix(lower, "s") && len(s) > 1:
+		return s[:len(s)-1]
+	default:
+		return s + "_item"
+	}
+}
+
+// hasSuffix reports whether s ends with any of suffixes.
+func hasSuffix(s string, suffixes ...string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
 		}
 	}
 
-	return result.String()
+	return false
 }
@@ -1,7 +1,10 @@
 package codegen
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/richerve/yaml2go/pkg/schema"
 )
 
 func TestFieldTag_String(t *testing.T) {
@@ -17,7 +20,7 @@ func TestFieldTag_String(t *testing.T) {
 				Value:  "username",
 				Flags:  []string{},
 			},
-			expected: "`json:\"username\"`",
+			expected: "json:\"username\"",
 		},
 		{
 			name: "tag with single flag",
@@ -26,7 +29,7 @@ func TestFieldTag_String(t *testing.T) {
 				Value:  "email",
 				Flags:  []string{"omitempty"},
 			},
-			expected: "`json:\"email,omitempty\"`",
+			expected: "json:\"email,omitempty\"",
 		},
 		{
 			name: "tag with multiple flags",
@@ -35,7 +38,24 @@ func TestFieldTag_String(t *testing.T) {
 				Value:  "password",
 				Flags:  []string{"omitempty", "readonly"},
 			},
-			expected: "`json:\"password,omitempty,readonly\"`",
+			expected: "json:\"password,omitempty,readonly\"",
+		},
+		{
+			name: "dash key",
+			fieldTag: FieldTag{
+				Prefix: "json",
+				Value:  "-",
+			},
+			expected: "json:\"-,\"",
+		},
+		{
+			name: "dash key with flag",
+			fieldTag: FieldTag{
+				Prefix: "json",
+				Value:  "-",
+				Flags:  []string{"omitempty"},
+			},
+			expected: "json:\"-,omitempty\"",
 		},
 		{
 			name: "empty prefix",
@@ -76,6 +96,29 @@ func TestFieldTag_String(t *testing.T) {
 	}
 }
 
+func TestValidTagValue(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected bool
+	}{
+		{value: "name", expected: true},
+		{value: "-", expected: true},
+		{value: "a b:c.d", expected: true},
+		{value: "", expected: false},
+		{value: `a"b`, expected: false},
+		{value: "a`b", expected: false},
+		{value: "e,f", expected: false},
+		{value: `a\b`, expected: false},
+		{value: "a\tb", expected: false},
+	}
+
+	for _, tt := range tests {
+		if result := ValidTagValue(tt.value); result != tt.expected {
+			t.Errorf("ValidTagValue(%q) = %v, want %v", tt.value, result, tt.expected)
+		}
+	}
+}
+
 func TestFieldDef_String(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -87,11 +130,11 @@ func TestFieldDef_String(t *testing.T) {
 			field: FieldDef{
 				Name: "username",
 				Type: "string",
-				Tag: &FieldTag{
+				Tags: []FieldTag{{
 					Prefix: "json",
 					Value:  "username",
 					Flags:  []string{},
-				},
+				}},
 			},
 			expected: "Username string `json:\"username\"`",
 		},
@@ -100,11 +143,11 @@ func TestFieldDef_String(t *testing.T) {
 			field: FieldDef{
 				Name: "email",
 				Type: "string",
-				Tag: &FieldTag{
+				Tags: []FieldTag{{
 					Prefix: "json",
 					Value:  "email",
 					Flags:  []string{"omitempty"},
-				},
+				}},
 			},
 			expected: "Email string `json:\"email,omitempty\"`",
 		},
@@ -113,11 +156,11 @@ func TestFieldDef_String(t *testing.T) {
 			field: FieldDef{
 				Name: "password",
 				Type: "string",
-				Tag: &FieldTag{
+				Tags: []FieldTag{{
 					Prefix: "json",
 					Value:  "password",
 					Flags:  []string{"omitempty", "readonly"},
-				},
+				}},
 			},
 			expected: "Password string `json:\"password,omitempty,readonly\"`",
 		},
@@ -126,11 +169,11 @@ func TestFieldDef_String(t *testing.T) {
 			field: FieldDef{
 				Name: "user_name",
 				Type: "string",
-				Tag: &FieldTag{
+				Tags: []FieldTag{{
 					Prefix: "yaml",
 					Value:  "user_name",
 					Flags:  []string{},
-				},
+				}},
 			},
 			expected: "UserName string `yaml:\"user_name\"`",
 		},
@@ -139,11 +182,11 @@ func TestFieldDef_String(t *testing.T) {
 			field: FieldDef{
 				Name: "user-name",
 				Type: "string",
-				Tag: &FieldTag{
+				Tags: []FieldTag{{
 					Prefix: "json",
 					Value:  "user-name",
 					Flags:  []string{},
-				},
+				}},
 			},
 			expected: "UserName string `json:\"user-name\"`",
 		},
@@ -152,48 +195,73 @@ func TestFieldDef_String(t *testing.T) {
 			field: FieldDef{
 				Name: "items",
 				Type: "[]Item",
-				Tag: &FieldTag{
+				Tags: []FieldTag{{
 					Prefix: "json",
 					Value:  "items",
 					Flags:  []string{},
-				},
+				}},
 			},
 			expected: "Items []Item `json:\"items\"`",
 		},
 		{
-			name: "field with nil tag",
+			name: "field without tags",
 			field: FieldDef{
 				Name: "username",
 				Type: "string",
-				Tag:  nil,
 			},
 			expected: "Username string",
 		},
+		{
+			name: "field with multiple tags",
+			field: FieldDef{
+				Name: "username",
+				Type: "string",
+				Tags: []FieldTag{
+					{Prefix: "json", Value: "username", Flags: []string{"omitzero"}},
+					{Prefix: "yaml", Value: "username"},
+					{Prefix: "mapstructure", Value: "username", Flags: []string{"omitempty"}},
+				},
+			},
+			expected: "Username string `json:\"username,omitzero\" yaml:\"username\" mapstructure:\"username,omitempty\"`",
+		},
 		{
 			name: "field with empty tag",
 			field: FieldDef{
 				Name: "username",
 				Type: "string",
-				Tag: &FieldTag{
+				Tags: []FieldTag{{
 					Prefix: "",
 					Value:  "",
 					Flags:  []string{},
-				},
+				}},
 			},
 			expected: "Username string",
 		},
+		{
+			name: "renamed field",
+			field: FieldDef{
+				Name:   "foo-bar",
+				GoName: "FooBar2",
+				Type:   "string",
+				Tags: []FieldTag{{
+					Prefix: "json",
+					Value:  "foo-bar",
+				}},
+			},
+			expected: "FooBar2 string `json:\"foo-bar\"`",
+		},
 		{
 			name: "empty field name",
 			field: FieldDef{
 				Name: "",
 				Type: "string",
-				Tag: &FieldTag{
+				Tags: []FieldTag{{
 					Prefix: "json",
 					Value:  "field",
 					Flags:  []string{},
-				},
+				}},
 			},
-			expected: " string `json:\"field\"`",
+			expected: "X string `json:\"field\"`",
 		},
 	}
 
@@ -221,20 +289,20 @@ func TestStructDef_String(t *testing.T) {
 					{
 						Name: "username",
 						Type: "string",
-						Tag: &FieldTag{
+						Tags: []FieldTag{{
 							Prefix: "json",
 							Value:  "username",
 							Flags:  []string{},
-						},
+						}},
 					},
 					{
 						Name: "email",
 						Type: "string",
-						Tag: &FieldTag{
+						Tags: []FieldTag{{
 							Prefix: "json",
 							Value:  "email",
 							Flags:  []string{},
-						},
+						}},
 					},
 				},
 			},
@@ -242,6 +310,33 @@ func TestStructDef_String(t *testing.T) {
 	Username string ` + "`json:\"username\"`" + `
 	Email string ` + "`json:\"email\"`" + `
 }
+`,
+		},
+		{
+			name: "struct with comments",
+			structDef: StructDef{
+				Name:    "User",
+				Comment: "A user of the service",
+				Fields: []FieldDef{
+					{
+						Name:    "username",
+						Type:    "string",
+						Comment: "Login name\n\nmust be unique",
+					},
+					{
+						Name: "email",
+						Type: "string",
+					},
+				},
+			},
+			expected: `// A user of the service
+type User struct {
+	// Login name
+	//
+	// must be unique
+	Username string
+	Email string
+}
 `,
 		},
 		{
@@ -252,20 +347,20 @@ func TestStructDef_String(t *testing.T) {
 					{
 						Name: "username",
 						Type: "string",
-						Tag: &FieldTag{
+						Tags: []FieldTag{{
 							Prefix: "json",
 							Value:  "username",
 							Flags:  []string{},
-						},
+						}},
 					},
 					{
 						Name: "email",
 						Type: "string",
-						Tag: &FieldTag{
+						Tags: []FieldTag{{
 							Prefix: "json",
 							Value:  "email",
 							Flags:  []string{"omitempty"},
-						},
+						}},
 					},
 				},
 			},
@@ -293,38 +388,38 @@ func TestStructDef_String(t *testing.T) {
 					{
 						Name: "title",
 						Type: "string",
-						Tag: &FieldTag{
+						Tags: []FieldTag{{
 							Prefix: "yaml",
 							Value:  "title",
 							Flags:  []string{},
-						},
+						}},
 					},
 					{
 						Name: "tags",
 						Type: "[]string",
-						Tag: &FieldTag{
+						Tags: []FieldTag{{
 							Prefix: "yaml",
 							Value:  "tags",
 							Flags:  []string{},
-						},
+						}},
 					},
 					{
 						Name: "metadata",
 						Type: "map[string]interface{}",
-						Tag: &FieldTag{
+						Tags: []FieldTag{{
 							Prefix: "yaml",
 							Value:  "metadata",
 							Flags:  []string{},
-						},
+						}},
 					},
 					{
 						Name: "nested",
 						Type: "NestedStruct",
-						Tag: &FieldTag{
+						Tags: []FieldTag{{
 							Prefix: "yaml",
 							Value:  "nested",
 							Flags:  []string{},
-						},
+						}},
 					},
 				},
 			},
@@ -344,35 +439,35 @@ func TestStructDef_String(t *testing.T) {
 					{
 						Name: "api_key",
 						Type: "string",
-						Tag: &FieldTag{
+						Tags: []FieldTag{{
 							Prefix: "json",
 							Value:  "api_key",
 							Flags:  []string{},
-						},
+						}},
 					},
 					{
 						Name: "base_url",
 						Type: "string",
-						Tag: &FieldTag{
+						Tags: []FieldTag{{
 							Prefix: "json",
 							Value:  "base_url",
 							Flags:  []string{},
-						},
+						}},
 					},
 					{
 						Name: "timeout_seconds",
 						Type: "int",
-						Tag: &FieldTag{
+						Tags: []FieldTag{{
 							Prefix: "json",
 							Value:  "timeout_seconds",
 							Flags:  []string{},
-						},
+						}},
 					},
 				},
 			},
 			expected: `type Config struct {
-	ApiKey string ` + "`json:\"api_key\"`" + `
-	BaseUrl string ` + "`json:\"base_url\"`" + `
+	APIKey string ` + "`json:\"api_key\"`" + `
+	BaseURL string ` + "`json:\"base_url\"`" + `
 	TimeoutSeconds int ` + "`json:\"timeout_seconds\"`" + `
 }
 `,
@@ -389,100 +484,260 @@ func TestStructDef_String(t *testing.T) {
 	}
 }
 
-func TestCapitalize(t *testing.T) {
+func TestElementName(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
 		expected string
 	}{
 		{
-			name:     "basic string",
-			input:    "hello",
-			expected: "Hello",
+			name:     "plural with s",
+			input:    "items",
+			expected: "item",
 		},
 		{
-			name:     "snake_case",
-			input:    "user_name",
-			expected: "UserName",
+			name:     "plural with ies",
+			input:    "policies",
+			expected: "policy",
 		},
 		{
-			name:     "kebab-case",
-			input:    "user-name",
-			expected: "UserName",
+			name:     "plural with es",
+			input:    "boxes",
+			expected: "box",
 		},
 		{
-			name:     "mixed separators",
-			input:    "user_name-id",
-			expected: "UserNameId",
+			name:     "double s is not plural",
+			input:    "address",
+			expected: "address_item",
 		},
 		{
-			name:     "multiple underscores",
-			input:    "user__name",
-			expected: "UserName",
+			name:     "plural with sses",
+			input:    "addresses",
+			expected: "address",
 		},
 		{
-			name:     "multiple dashes",
-			input:    "user--name",
-			expected: "UserName",
+			name:     "plural with uses",
+			input:    "statuses",
+			expected: "status",
 		},
 		{
-			name:     "empty string",
-			input:    "",
-			expected: "",
+			name:     "plural with iases",
+			input:    "aliases",
+			expected: "alias",
 		},
 		{
-			name:     "single character",
-			input:    "a",
-			expected: "A",
+			name:     "singular with us",
+			input:    "status",
+			expected: "status_item",
 		},
 		{
-			name:     "already capitalized",
-			input:    "UserName",
-			expected: "UserName",
+			name:     "singular with is",
+			input:    "analysis",
+			expected: "analysis_item",
 		},
 		{
-			name:     "with numbers",
-			input:    "user_id_123",
-			expected: "UserId123",
+			name:     "plural with ses",
+			input:    "databases",
+			expected: "database",
 		},
 		{
-			name:     "starts with separator",
-			input:    "_user_name",
-			expected: "UserName",
+			name:     "singular key",
+			input:    "data",
+			expected: "data_item",
 		},
 		{
-			name:     "ends with separator",
-			input:    "user_name_",
-			expected: "UserName",
+			name:     "snake_case plural",
+			input:    "user_roles",
+			expected: "user_role",
 		},
 		{
-			name:     "only separators",
-			input:    "___",
-			expected: "",
+			name:     "empty string",
+			input:    "",
+			expected: "_item",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ElementName(tt.input)
+			if result != tt.expected {
+				t.Errorf("ElementName(%v) = %v, want %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFile_Format(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        File
+		expected    string
+		expectError bool
+	}{
+		{
+			name: "structs without imports",
+			file: File{
+				Package: "config",
+				Structs: []StructDef{
+					{
+						Name: "Config",
+						Fields: []FieldDef{
+							{Name: "name", Type: "*string", Tags: []FieldTag{{Prefix: "json", Value: "name"}}},
+							{Name: "port", Type: "*int", Tags: []FieldTag{{Prefix: "json", Value: "port"}}},
+						},
+					},
+				},
+			},
+			expected: `// Code generated by yaml2go. DO NOT EDIT.
+
+package config
+
+type Config struct {
+	Name *string ` + "`json:\"name\"`" + `
+	Port *int    ` + "`json:\"port\"`" + `
+}
+`,
+		},
+		{
+			name: "header naming the source",
+			file: File{
+				Package: "config",
+				Source:  "dev.yaml prod.yaml",
+				Structs: []StructDef{{Name: "Config", Fields: []FieldDef{{Name: "name", Type: "string"}}}},
+			},
+			expected: `// Code generated by yaml2go from dev.yaml prod.yaml. DO NOT EDIT.
+
+package config
+
+type Config struct {
+	Name string
+}
+`,
 		},
 		{
-			name:     "complex case",
-			input:    "api_key_config_v2",
-			expected: "ApiKeyConfigV2",
+			name: "imports computed from field types",
+			file: File{
+				Package: "config",
+				Structs: []StructDef{
+					{
+						Name: "Config",
+						Fields: []FieldDef{
+							{Name: "created", Type: "*time.Time", Schema: schema.Type{Kind: schema.KindTimestamp}, Pointer: true},
+							{Name: "data", Type: "map[string]any", Schema: schema.Type{Kind: schema.KindMap}},
+						},
+					},
+				},
+			},
+			expected: `// Code generated by yaml2go. DO NOT EDIT.
+
+package config
+
+import (
+	"time"
+)
+
+type Config struct {
+	Created *time.Time
+	Data    map[string]any
+}
+`,
 		},
 		{
-			name:     "camelCase input",
-			input:    "userName",
-			expected: "UserName",
+			name: "values with their imports and helper",
+			file: File{
+				Package: "config",
+				Structs: []StructDef{
+					{
+						Name: "Config",
+						Fields: []FieldDef{
+							{Name: "name", Type: "*string"},
+							{Name: "created", Type: "time.Time"},
+						},
+					},
+				},
+				Values: []ValueDef{
+					{
+						Name:     "ConfigValue",
+						Value:    "Config{\n\tName: ptr(\"app\"),\n\tCreated: time.Date(2024, time.February, 3, 0, 0, 0, 0, time.UTC),\n}",
+						Imports:  []string{"time"},
+						Pointers: true,
+						Comment:  "ConfigValue holds the data of config.yaml.",
+					},
+				},
+			},
+			expected: `// Code generated by yaml2go. DO NOT EDIT.
+
+package config
+
+import (
+	"time"
+)
+
+type Config struct {
+	Name    *string
+	Created time.Time
+}
+
+// ConfigValue holds the data of config.yaml.
+var ConfigValue = Config{
+	Name:    ptr("app"),
+	Created: time.Date(2024, time.February, 3, 0, 0, 0, 0, time.UTC),
+}
+
+// ptr returns a pointer to v, for the pointer fields of the values.
+func ptr[T any](v T) *T {
+	return &v
+}
+`,
 		},
 		{
-			name:     "PascalCase input",
-			input:    "UserName",
-			expected: "UserName",
+			name: "invalid package name",
+			file: File{
+				Package: "1config",
+			},
+			expectError: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := Capitalize(tt.input)
-			if result != tt.expected {
-				t.Errorf("Capitalize(%v) = %v, want %v", result, result, tt.expected)
+			result, err := tt.file.Format()
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("File.Format() = %v, want %v", string(result), tt.expected)
 			}
 		})
 	}
 }
+
+func TestFile_Imports(t *testing.T) {
+	file := File{
+		Structs: []StructDef{
+			{Fields: []FieldDef{{Name: "a", Type: "[]*time.Time", Schema: schema.ArrayOf(schema.Type{Kind: schema.KindTimestamp, Nullable: true})}}},
+			{Fields: []FieldDef{{Name: "b", Type: "*string", Schema: schema.Type{Kind: schema.KindString}, Pointer: true}, {Name: "c", Type: "Nested", Schema: schema.ObjectOf("Nested")}}},
+		},
+	}
+
+	result := strings.Join(file.Imports(), ",")
+	if result != "time" {
+		t.Errorf("File.Imports() = %v, want %v", result, "time")
+	}
+
+	// Values list their imports, string literals don't import packages
+	file.Values = []ValueDef{
+		{Name: "A", Value: `Nested{B: "os.Exit"}`},
+		{Name: "B", Value: "Nested{}", Imports: []string{"encoding/base64"}},
+	}
+	result = strings.Join(file.Imports(), ",")
+	if result != "encoding/base64,time" {
+		t.Errorf("File.Imports() = %v, want %v", result, "encoding/base64,time")
+	}
+}
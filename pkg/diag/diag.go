@@ -0,0 +1,133 @@
+package diag
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+)
+
+// Severity tells the problems that stop the generation from the ones the
+// generator worked around.
+type Severity int
+
+const (
+	// Error is the zero Severity, the input can't be turned into valid Go.
+	Error Severity = iota
+	// Warning reports a change made to produce valid Go, such as a renamed
+	// field.
+	Warning
+)
+
+// Diagnostic is a problem found while generating code, located in the YAML
+// input by file, line, column and the path of keys leading to it.
+type Diagnostic struct {
+	// File is the name of the YAML file, empty when the input has no name
+	File     string
+	Line     int
+	Column   int
+	Path     string
+	Message  string
+	Severity Severity
+}
+
+// New returns a diagnostic positioned at node. Nodes without a token, such
+// as an empty document, leave the position at zero.
+func New(node ast.Node, path []string, format string, args ...any) Diagnostic {
+	d := Diagnostic{
+		Path:    strings.Join(path, "."),
+		Message: fmt.Sprintf(format, args...),
+	}
+
+	if node != nil {
+		if tk := node.GetToken(); tk != nil && tk.Position != nil {
+			d.Line = tk.Position.Line
+			d.Column = tk.Position.Column
+		}
+	}
+
+	return d
+}
+
+// Warn returns a warning positioned at node, see New.
+func Warn(node ast.Node, path []string, format string, args ...any) Diagnostic {
+	d := New(node, path, format, args...)
+	d.Severity = Warning
+
+	return d
+}
+
+func (d Diagnostic) Error() string {
+	var sb strings.Builder
+
+	if d.File != "" {
+		sb.WriteString(d.File)
+		if d.Line > 0 {
+			sb.WriteString(":")
+		} else {
+			sb.WriteString(": ")
+		}
+	}
+	if d.Line > 0 {
+		fmt.Fprintf(&sb, "%d:%d: ", d.Line, d.Column)
+	}
+	if d.Path != "" {
+		fmt.Fprintf(&sb, "%s: ", d.Path)
+	}
+	if d.Severity == Warning {
+		sb.WriteString("warning: ")
+	}
+	sb.WriteString(d.Message)
+
+	return sb.String()
+}
+
+// List is a list of diagnostics, usable as an error holding all of them.
+type List []Diagnostic
+
+func (l List) Error() string {
+	messages := make([]string, 0, len(l))
+	for _, d := range l {
+		messages = append(messages, d.Error())
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// Err returns the errors of the list as an error, or nil when it holds none.
+// Warnings alone don't make an error.
+func (l List) Err() error {
+	errs := l.filter(Error)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// Warnings returns the warnings of the list.
+func (l List) Warnings() List {
+	return l.filter(Warning)
+}
+
+func (l List) filter(severity Severity) List {
+	var result List
+	for _, d := range l {
+		if d.Severity == severity {
+			result = append(result, d)
+		}
+	}
+
+	return result
+}
+
+// Sort orders the diagnostics by their position in the input.
+func (l List) Sort() {
+	sort.SliceStable(l, func(i, j int) bool {
+		if l[i].Line != l[j].Line {
+			return l[i].Line < l[j].Line
+		}
+		return l[i].Column < l[j].Column
+	})
+}
@@ -0,0 +1,162 @@
+package diag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+func TestNew(t *testing.T) {
+	file, err := parser.ParseBytes([]byte("name: john\nserver:\n  port: 80\n"), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	server := file.Docs[0].Body.(*ast.MappingNode).Values[1]
+	port := server.Value.(*ast.MappingNode).Values[0]
+
+	tests := []struct {
+		name     string
+		node     ast.Node
+		path     []string
+		expected Diagnostic
+	}{
+		{
+			name: "positioned at key",
+			node: port.Key,
+			path: []string{"server", "port"},
+			expected: Diagnostic{
+				Line:    3,
+				Column:  3,
+				Path:    "server.port",
+				Message: "message 1",
+			},
+		},
+		{
+			name: "nil node",
+			node: nil,
+			path: []string{},
+			expected: Diagnostic{
+				Message: "message 1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := New(tt.node, tt.path, "message %d", 1)
+			if result != tt.expected {
+				t.Errorf("New() = %+v, want %+v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDiagnostic_Error(t *testing.T) {
+	tests := []struct {
+		name       string
+		diagnostic Diagnostic
+		expected   string
+	}{
+		{
+			name:       "position and path",
+			diagnostic: Diagnostic{Line: 3, Column: 5, Path: "server.port", Message: "bad"},
+			expected:   "3:5: server.port: bad",
+		},
+		{
+			name:       "no position",
+			diagnostic: Diagnostic{Path: "server", Message: "bad"},
+			expected:   "server: bad",
+		},
+		{
+			name:       "message only",
+			diagnostic: Diagnostic{Message: "bad"},
+			expected:   "bad",
+		},
+		{
+			name:       "warning",
+			diagnostic: Diagnostic{Line: 1, Column: 1, Path: "server", Message: "renamed", Severity: Warning},
+			expected:   "1:1: server: warning: renamed",
+		},
+		{
+			name:       "file and position",
+			diagnostic: Diagnostic{File: "dev.yaml", Line: 3, Column: 5, Path: "server.port", Message: "bad"},
+			expected:   "dev.yaml:3:5: server.port: bad",
+		},
+		{
+			name:       "file without position",
+			diagnostic: Diagnostic{File: "dev.yaml", Message: "bad"},
+			expected:   "dev.yaml: bad",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.diagnostic.Error()
+			if result != tt.expected {
+				t.Errorf("Diagnostic.Error() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	var empty List
+	if err := empty.Err(); err != nil {
+		t.Errorf("Expected nil error for an empty list, got %v", err)
+	}
+
+	l := List{
+		{Line: 4, Column: 1, Message: "second"},
+		{Line: 2, Column: 7, Message: "first"},
+	}
+	l.Sort()
+
+	err := l.Err()
+	if err == nil {
+		t.Fatal("Expected an error for a non-empty list")
+	}
+
+	expected := "2:7: first\n4:1: second"
+	if err.Error() != expected {
+		t.Errorf("List.Error() = %v, want %v", err.Error(), expected)
+	}
+
+	var diags List
+	if !errors.As(err, &diags) || len(diags) != 2 {
+		t.Errorf("Expected the error to unwrap to a List of 2 diagnostics, got %v", diags)
+	}
+}
+
+func TestList_Warnings(t *testing.T) {
+	l := List{
+		{Line: 1, Column: 1, Message: "renamed", Severity: Warning},
+	}
+	if err := l.Err(); err != nil {
+		t.Errorf("Expected nil error for a list of warnings, got %v", err)
+	}
+
+	l = append(l, Diagnostic{Line: 2, Column: 1, Message: "bad"})
+
+	var errs List
+	if !errors.As(l.Err(), &errs) || len(errs) != 1 || errs[0].Message != "bad" {
+		t.Errorf("Expected the error to hold only the error, got %v", errs)
+	}
+
+	warnings := l.Warnings()
+	if len(warnings) != 1 || warnings[0].Message != "renamed" {
+		t.Errorf("Warnings() = %v, want the warning only", warnings)
+	}
+}
+
+func TestWarn(t *testing.T) {
+	d := Warn(nil, []string{"server"}, "renamed %s", "x")
+	if d.Severity != Warning {
+		t.Errorf("Warn() severity = %v, want %v", d.Severity, Warning)
+	}
+	if d.Error() != "server: warning: renamed x" {
+		t.Errorf("Warn().Error() = %v", d.Error())
+	}
+}
@@ -0,0 +1,189 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change.
+const context = 3
+
+// Unified returns the unified diff turning a into b, with a and b named
+// aName and bName in the header, or "" when they are equal.
+func Unified(aName, bName string, a, b []byte) string {
+	if string(a) == string(b) {
+		return ""
+	}
+
+	ops := edits(splitLines(string(a)), splitLines(string(b)))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+	for _, h := range hunks(ops) {
+		h.write(&sb, ops)
+	}
+
+	return sb.String()
+}
+
+// splitLines splits s after each newline, the last line lacks one when s
+// doesn't end with a newline.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+type kind byte
+
+const (
+	keep   kind = ' '
+	remove kind = '-'
+	add    kind = '+'
+)
+
+// op is a line kept, deleted from a or inserted from b.
+type op struct {
+	kind kind
+	line string
+}
+
+// edits returns the shortest edit script turning a into b, computed with
+// the Myers algorithm.
+func edits(a, b []string) []op {
+	n, m := len(a), len(b)
+	limit := n + m
+	offset := limit + 1
+
+	// v holds the furthest x reached on each diagonal k = x - y, trace the
+	// state of v before each step to walk the script back
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+
+search:
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	var ops []op
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			ops = append(ops, op{kind: keep, line: a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, op{kind: add, line: b[y-1]})
+			} else {
+				ops = append(ops, op{kind: remove, line: a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+
+	return ops
+}
+
+// hunk is the range [start, end) of ops shown together.
+type hunk struct {
+	start, end int
+}
+
+// hunks groups the changes of ops with their surrounding context, changes
+// whose contexts touch share a hunk.
+func hunks(ops []op) []hunk {
+	var result []hunk
+	for i, o := range ops {
+		if o.kind == keep {
+			continue
+		}
+
+		start, end := max(i-context, 0), min(i+1+context, len(ops))
+		if last := len(result) - 1; last >= 0 && start <= result[last].end {
+			result[last].end = end
+		} else {
+			result = append(result, hunk{start: start, end: end})
+		}
+	}
+
+	return result
+}
+
+// write writes the header and lines of h to sb.
+func (h hunk) write(sb *strings.Builder, ops []op) {
+	// Lines are numbered from 1, an empty range is numbered after the line
+	// preceding it
+	aStart, bStart := 1, 1
+	for _, o := range ops[:h.start] {
+		if o.kind != add {
+			aStart++
+		}
+		if o.kind != remove {
+			bStart++
+		}
+	}
+
+	aLen, bLen := 0, 0
+	for _, o := range ops[h.start:h.end] {
+		if o.kind != add {
+			aLen++
+		}
+		if o.kind != remove {
+			bLen++
+		}
+	}
+	if aLen == 0 {
+		aStart--
+	}
+	if bLen == 0 {
+		bStart--
+	}
+
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+	for _, o := range ops[h.start:h.end] {
+		sb.WriteByte(byte(o.kind))
+		sb.WriteString(o.line)
+		if !strings.HasSuffix(o.line, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/richerve/yaml2go/pkg/jsonschema"
+)
+
+// Format selects what Generate produces from the documents.
+type Format string
+
+const (
+	// FormatGo generates the Go types of the documents.
+	FormatGo Format = "go"
+	// FormatJSONSchema generates a JSON Schema, draft 2020-12, validating the
+	// documents. Its definitions are named after the Go structs.
+	FormatJSONSchema Format = "jsonschema"
+)
+
+// String and Set let a Format be used as a command line flag value.
+func (f *Format) String() string {
+	return string(*f)
+}
+
+func (f *Format) Set(value string) error {
+	format, err := ParseFormat(value)
+	if err != nil {
+		return err
+	}
+
+	*f = format
+	return nil
+}
+
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatGo, FormatJSONSchema:
+		return Format(s), nil
+	default:
+		return "", fmt.Errorf("unknown format %q, expected %q or %q", s, FormatGo, FormatJSONSchema)
+	}
+}
+
+// generateJSONSchema returns the JSON Schema of the documents in files. The
+// options of the Go code are rejected, they would have no effect.
+func (g *Generator) generateJSONSchema(files []*ast.File) (string, error) {
+	switch {
+	case g.opts.Package != "":
+		return "", errors.New("a package name only applies to the Go format")
+	case g.opts.Split != "":
+		return "", errors.New("splitting into files only applies to the Go format")
+	case g.opts.Values:
+		return "", errors.New("values only apply to the Go format")
+	case g.opts.Verify:
+		return "", errors.New("verifying only applies to the Go format")
+	}
+
+	s, err := g.Schema(files...)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := jsonschema.Generate(s)
+	return string(data), err
+}
@@ -0,0 +1,15 @@
+package generator
+
+import "testing"
+
+func TestParseFormat(t *testing.T) {
+	for _, format := range []Format{FormatGo, FormatJSONSchema} {
+		if result, err := ParseFormat(string(format)); err != nil || result != format {
+			t.Errorf("ParseFormat(%q) = %v, %v, want %v", format, result, err, format)
+		}
+	}
+
+	if _, err := ParseFormat("proto"); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+}
@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"errors"
 	"fmt"
 	"slices"
 	"sort"
@@ -8,69 +9,747 @@ import (
 
 	"github.com/goccy/go-yaml/ast"
 	"github.com/richerve/yaml2go/pkg/codegen"
+	"github.com/richerve/yaml2go/pkg/diag"
+	"github.com/richerve/yaml2go/pkg/golang"
+	"github.com/richerve/yaml2go/pkg/ident"
+	"github.com/richerve/yaml2go/pkg/inference"
+	"github.com/richerve/yaml2go/pkg/naming"
+	"github.com/richerve/yaml2go/pkg/resolve"
+	"github.com/richerve/yaml2go/pkg/schema"
+	"github.com/richerve/yaml2go/pkg/verify"
 	"github.com/richerve/yaml2go/pkg/visitor"
 )
 
+// Options configures the generated code. The CLI flags map one to one onto
+// these fields, DefaultOptions holds the values used when no flag is given.
+type Options struct {
+	// Tags are the struct tag keys written on every field, e.g. json, yaml
+	// and mapstructure. No tags are generated when empty.
+	Tags []string
+	// OmitZero uses the omitzero tag flag for empty values instead of
+	// omitempty, on the tags without a flag in OmitFlags.
+	OmitZero bool
+	// OmitFlags selects the flag leaving out the empty values of each tag,
+	// omitempty or omitzero, e.g. omitzero for json only as not every
+	// decoder understands it.
+	OmitFlags map[string]string
+	// Cases selects the naming convention of the values of each tag, e.g.
+	// camel for json and keep for yaml. Tags without one keep the YAML key.
+	Cases map[string]ident.Case
+	// Pointers selects which scalar fields are pointers. When empty it is
+	// PointerOptional with MergeDocuments, so that samples only leave out
+	// the optional keys, and PointerAlways otherwise.
+	Pointers inference.PointerPolicy
+	// Naming selects how colliding struct names are made unique, Parent is
+	// used when empty.
+	Naming naming.Strategy
+	// Package generates a complete, gofmt'd Go file in this package instead
+	// of bare type declarations.
+	Package string
+	// Source names the YAML the complete files are generated from in their
+	// header, e.g. "config.yaml", so that the files of different sources
+	// can be told apart. The header names no source when empty.
+	Source string
+	// Split selects how GenerateFiles divides the code into files, a single
+	// file is generated when empty.
+	Split Split
+	// Format selects the output of Generate, FormatGo when empty. The
+	// options of the Go code, such as Package and Values, can't be used with
+	// FormatJSONSchema.
+	Format Format
+	// MergeDocuments treats every document of every file as a sample of the
+	// same configuration, merged into a single root struct: the keys
+	// missing from some samples are optional, with an omitempty flag and a
+	// pointer for structs, and differing types widen. Use it with
+	// PointerOptional for the keys present in every sample to be plain
+	// values.
+	MergeDocuments bool
+	// ValidateRequired adds a validate:"required" struct tag to the fields
+	// that are not optional, for github.com/go-playground/validator.
+	ValidateRequired bool
+	// Values generates after the types a variable for each document holding
+	// its data, typed by its root struct, e.g. var DocumentValue = Document{}.
+	Values bool
+	// Verify type-checks the generated code before returning it, reporting
+	// the code that doesn't compile at the YAML that produced it.
+	Verify bool
+	// Warn is called with each warning, such as a field renamed because its
+	// key produces the same name as another. Warnings are dropped when nil.
+	// A Generator used from multiple goroutines calls it concurrently.
+	Warn func(diag.Diagnostic)
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Tags:   []string{"json"},
+		Naming: naming.Parent,
+	}
+}
+
+// Generator turns parsed YAML into Go code. Every call to Generate starts
+// from scratch and only reads the options, so a Generator can be reused for
+// many files and used from multiple goroutines at once.
 type Generator struct {
-	structs map[string]codegen.StructDef
+	opts Options
 }
 
-func New() *Generator {
+func New(opts Options) *Generator {
 	return &Generator{
-		structs: make(map[string]codegen.StructDef),
+		opts: opts,
 	}
 }
 
-func (g *Generator) Generate(file *ast.File, tagPrefix string, useOmitZero bool) string {
-	// Process each document in the file using Walk
-	for i, doc := range file.Docs {
-		rootName := g.determineDocumentName(doc, i, len(file.Docs))
+// Generate returns the type declarations for every document in files, or a
+// complete Go source file when Options.Package is set. The documents of all
+// the files make a single set of types: documents at the same position of
+// different files, such as the configuration of each environment, are
+// merged into one root struct holding the union of their keys.
+//
+// When the input can't be turned into valid Go the error is a diag.List
+// locating each problem in the YAML by the ast.File name, line and column,
+// warnings are reported to Options.Warn.
+//
+// With FormatJSONSchema the result is instead a JSON Schema validating the
+// documents.
+func (g *Generator) Generate(files ...*ast.File) (string, error) {
+	switch g.opts.Format {
+	case "", FormatGo:
+	case FormatJSONSchema:
+		return g.generateJSONSchema(files)
+	default:
+		_, err := ParseFormat(string(g.opts.Format))
+		return "", err
+	}
 
-		v := visitor.NewASTVisitor(g.structs, []string{rootName}, tagPrefix, useOmitZero)
-		ast.Walk(v, doc)
+	c, err := g.code(files)
+	if err != nil {
+		return "", err
 	}
 
-	var result strings.Builder
+	if g.opts.Package != "" {
+		source, err := g.generateFile(c.structs, c.values)
+		return string(source), err
+	}
 
-	// Generate root structs first in order
-	var rootNames []string
-	for i, doc := range file.Docs {
-		rootName := g.determineDocumentName(doc, i, len(file.Docs))
-		if _, exists := g.structs[rootName]; exists {
-			rootNames = append(rootNames, rootName)
+	f := codegen.File{
+		Structs: c.structs,
+		Values:  c.values,
+	}
+
+	return f.Declarations(), nil
+}
+
+// GenerateFiles returns complete Go source files for the documents in files,
+// divided as selected by Options.Split. Options.Package is required, and
+// FormatGo, the errors are the ones of Generate.
+func (g *Generator) GenerateFiles(files ...*ast.File) ([]File, error) {
+	if g.opts.Format != "" && g.opts.Format != FormatGo {
+		return nil, fmt.Errorf("generating files requires the %q format", FormatGo)
+	}
+	if g.opts.Package == "" {
+		return nil, errors.New("generating files requires a package name")
+	}
+	if g.opts.Split != "" {
+		if _, err := ParseSplit(string(g.opts.Split)); err != nil {
+			return nil, err
 		}
 	}
 
-	// Write root structs
-	for i, rootName := range rootNames {
-		if i > 0 {
-			result.WriteString("\n")
+	c, err := g.code(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []fileGroup
+	switch g.opts.Split {
+	case SplitDocument:
+		groups = groupByRoot(c.structs, c.roots)
+	case SplitStruct:
+		for _, s := range c.structs {
+			groups = append(groups, fileGroup{name: s.Name, structs: []codegen.StructDef{s}})
 		}
-		_, err := result.WriteString(g.structs[rootName].String())
+	default:
+		groups = []fileGroup{{name: defaultFileName, structs: c.structs, values: c.values}}
+	}
+	// Split types leave the values, and the helper they share, to a file of
+	// their own
+	if (g.opts.Split == SplitDocument || g.opts.Split == SplitStruct) && len(c.values) > 0 {
+		groups = append(groups, fileGroup{name: valuesFileName, values: c.values})
+	}
+
+	result := make([]File, 0, len(groups))
+	used := make(map[string]bool)
+	for _, group := range groups {
+		source, err := g.generateFile(group.structs, group.values)
 		if err != nil {
-			return ""
+			return nil, err
+		}
+		result = append(result, File{
+			Name:   fileName(group.name, used),
+			Source: source,
+		})
+	}
+
+	return result, nil
+}
+
+// Root is the root struct generated for a document.
+type Root struct {
+	// Name is the struct, empty when the document produces none, such as a
+	// scalar document.
+	Name string
+	// Key is the single key of the document holding the mapping the struct
+	// was generated from, empty when the struct holds the whole document.
+	Key string
+}
+
+// Roots returns the root struct generated for each document of files, by
+// file.
+func (g *Generator) Roots(files ...*ast.File) [][]Root {
+	s, _ := g.inferSchema(files)
+	docs, _ := g.documents(files)
+
+	result := make([][]Root, len(files))
+	for _, doc := range docs {
+		var root Root
+		if slices.Contains(s.Roots, doc.root) {
+			root.Name = doc.root
+			if doc.body != ast.Node(doc.node) {
+				root.Key = naming.KeyName(doc.node.Body.(*ast.MappingNode).Values[0].Key)
+			}
+		}
+		result[doc.file] = append(result[doc.file], root)
+	}
+
+	return result
+}
+
+// code is the generated declarations.
+type code struct {
+	// structs follow the order of the schema objects, see inferSchema
+	structs []codegen.StructDef
+	// values are set when Options.Values is
+	values []codegen.ValueDef
+	roots  []string
+}
+
+// code validates the options and returns the code generated for files.
+// Warnings are reported to Options.Warn.
+func (g *Generator) code(files []*ast.File) (code, error) {
+	if g.opts.Pointers != "" {
+		if _, err := inference.ParsePointerPolicy(string(g.opts.Pointers)); err != nil {
+			return code{}, err
+		}
+	}
+	if g.opts.Naming != "" {
+		if _, err := naming.ParseStrategy(string(g.opts.Naming)); err != nil {
+			return code{}, err
+		}
+	}
+	if err := validateTags(g.opts.Tags); err != nil {
+		return code{}, err
+	}
+	if err := validateCases(g.opts.Cases, g.opts.Tags); err != nil {
+		return code{}, err
+	}
+	if err := validateOmitFlags(g.opts.OmitFlags, g.opts.Tags); err != nil {
+		return code{}, err
+	}
+	if g.opts.ValidateRequired && slices.Contains(g.opts.Tags, golang.ValidateTag) {
+		return code{}, fmt.Errorf("struct tag key %q is generated for the required fields, it can't be in the tags as well", golang.ValidateTag)
+	}
+
+	s, fileDiags := g.inferSchema(files)
+	structs, structDiags := golang.Structs(s, g.structOptions())
+	diags := sortDiagnostics(files, fileDiags, structDiags)
+	if err := g.report(diags); err != nil {
+		return code{}, err
+	}
+
+	c := code{structs: structs, roots: s.Roots}
+	if g.opts.Values {
+		values, diags := g.values(files, structs)
+		if err := diags.Err(); err != nil {
+			return code{}, err
+		}
+		c.values = values
+	}
+
+	if g.opts.Verify {
+		if err := verifyCode(c.structs, c.values); err != nil {
+			return code{}, err
+		}
+	}
+
+	return c, nil
+}
+
+// verifyPackage is the package of the file type-checked by verifyCode, its
+// name doesn't change the result.
+const verifyPackage = "generated"
+
+// verifyCode type-checks structs and values as a complete Go file and
+// returns the problems found as a diag.List located at the YAML that
+// produced them.
+func verifyCode(structs []codegen.StructDef, values []codegen.ValueDef) error {
+	f := codegen.File{
+		Package: verifyPackage,
+		Structs: structs,
+		Values:  values,
+	}
+
+	errs := verify.Source([]byte(f.String()))
+	if len(errs) == 0 {
+		return nil
+	}
+
+	diags := make(diag.List, 0, len(errs))
+	for _, e := range errs {
+		diags = append(diags, sourceDiagnostic(structs, values, e))
+	}
+
+	return diags
+}
+
+// sourceDiagnostic returns e located at the source of the field, struct or
+// value holding it, or without position when the code isn't part of one.
+func sourceDiagnostic(structs []codegen.StructDef, values []codegen.ValueDef, e verify.Error) diag.Diagnostic {
+	d := diag.Diagnostic{
+		Message: "generated code doesn't compile: " + e.Message,
+	}
+
+	for _, v := range values {
+		if v.Name == e.Struct {
+			d.File, d.Path, d.Line, d.Column = v.Source.File, v.Source.Path, v.Source.Line, v.Source.Column
+			return d
+		}
+	}
+
+	for _, s := range structs {
+		if s.Name != e.Struct {
+			continue
+		}
+
+		source := s.Source
+		for _, field := range s.Fields {
+			if e.Field != "" && field.FieldName() == e.Field {
+				source = field.Source
+				break
+			}
+		}
+		d.File, d.Path, d.Line, d.Column = source.File, source.Path, source.Line, source.Column
+		break
+	}
+
+	return d
+}
+
+// validateTags checks that tags are distinct keys of a struct tag, which
+// can't be empty or hold spaces, quotes, colons or control characters.
+func validateTags(tags []string) error {
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		if tag == "" || strings.ContainsFunc(tag, func(r rune) bool {
+			return r <= ' ' || r == '"' || r == ':' || r == 0x7f
+		}) {
+			return fmt.Errorf("invalid struct tag key %q", tag)
+		}
+		if seen[tag] {
+			return fmt.Errorf("duplicate struct tag key %q", tag)
+		}
+		seen[tag] = true
+	}
+
+	return nil
+}
+
+// validateCases checks that cases are known and only given for the tags
+// that are generated.
+func validateCases(cases map[string]ident.Case, tags []string) error {
+	for tag, c := range cases {
+		if _, err := ident.ParseCase(string(c)); err != nil {
+			return fmt.Errorf("struct tag key %q: %w", tag, err)
+		}
+		if !slices.Contains(tags, tag) {
+			return fmt.Errorf("case given for struct tag key %q which is not generated", tag)
+		}
+	}
+
+	return nil
+}
+
+// validateOmitFlags checks that flags are known and only given for the tags
+// that are generated.
+func validateOmitFlags(flags map[string]string, tags []string) error {
+	for tag, flag := range flags {
+		if _, err := golang.ParseOmitFlag(flag); err != nil {
+			return fmt.Errorf("struct tag key %q: %w", tag, err)
+		}
+		if !slices.Contains(tags, tag) {
+			return fmt.Errorf("omit flag given for struct tag key %q which is not generated", tag)
+		}
+	}
+
+	return nil
+}
+
+// generateFile renders structs and values as a complete Go source file,
+// with the generated code header and the imports they require, formatted
+// with gofmt.
+func (g *Generator) generateFile(structs []codegen.StructDef, values []codegen.ValueDef) ([]byte, error) {
+	f := codegen.File{
+		Package: g.opts.Package,
+		Source:  g.opts.Source,
+		Structs: structs,
+		Values:  values,
+	}
+
+	return f.Format()
+}
+
+// document is a resolved document with the name of its root struct and the
+// index of the file holding it.
+type document struct {
+	node *ast.DocumentNode
+	// body is the node the root struct is generated from, see rootNode
+	body ast.Node
+	root string
+	file int
+	// index is the position of the document in its file
+	index int
+}
+
+// documents resolves the documents of files and names their root struct,
+// along with the problems found while resolving each file.
+func (g *Generator) documents(files []*ast.File) ([]document, []diag.List) {
+	// Documents are numbered by their position in their file, the documents
+	// at the same position of different files share their root struct. A
+	// single document per file is named Document unless another file holds
+	// several
+	totalDocs := 0
+	for _, file := range files {
+		totalDocs = max(totalDocs, len(file.Docs))
+	}
+
+	// Anchors, aliases and merge keys are expanded first, the rest of the
+	// generation only sees plain mappings, sequences and scalars
+	fileDiags := make([]diag.List, len(files))
+	var docs []document
+	for fi, file := range files {
+		for i, doc := range file.Docs {
+			resolved, resolveDiags := resolve.Document(doc)
+			docs = append(docs, document{
+				node:  resolved,
+				body:  rootNode(resolved),
+				root:  g.determineDocumentName(resolved, i, totalDocs),
+				file:  fi,
+				index: i,
+			})
+			fileDiags[fi] = append(fileDiags[fi], resolveDiags...)
+		}
+	}
+
+	switch {
+	case g.opts.MergeDocuments:
+		mergeDocuments(docs)
+	case len(files) > 1:
+		// The documents at each position are named together, a key naming
+		// the document of a single file would split the union
+		for i := range totalDocs {
+			var position []document
+			var indexes []int
+			for di, doc := range docs {
+				if doc.index == i {
+					position = append(position, doc)
+					indexes = append(indexes, di)
+				}
+			}
+			nameTogether(position, documentName(i, totalDocs))
+			for pi, di := range indexes {
+				docs[di] = position[pi]
+			}
+		}
+	}
+
+	return docs, fileDiags
+}
+
+// nameTogether gives the documents of docs, found at the same position of
+// different files, the same root struct. Their name is kept when they all
+// have it, otherwise they are merged as a whole into fallback.
+func nameTogether(docs []document, fallback string) {
+	same := true
+	var first *document
+	for i, doc := range docs {
+		if _, ok := doc.node.Body.(*ast.MappingNode); !ok {
+			// Documents without keys have no root struct
+			continue
+		}
+		if first == nil {
+			first = &docs[i]
+			continue
+		}
+		if doc.root != first.root || (doc.body == ast.Node(doc.node)) != (first.body == ast.Node(first.node)) {
+			same = false
+			break
+		}
+	}
+	if same {
+		return
+	}
+
+	for i := range docs {
+		docs[i].root = fallback
+		docs[i].body = docs[i].node
+	}
+}
+
+// mergeDocuments gives every document the same root struct. The samples
+// sharing the single key holding their mapping are named after it, other
+// samples are merged as a whole into Document.
+func mergeDocuments(docs []document) {
+	root := ""
+	for _, doc := range docs {
+		if _, ok := doc.node.Body.(*ast.MappingNode); !ok {
+			// Samples without keys, such as an empty document, don't
+			// decide the name
+			continue
+		}
+		if doc.body == ast.Node(doc.node) || (root != "" && doc.root != root) {
+			root = ""
+			break
+		}
+		root = doc.root
+	}
+
+	for i := range docs {
+		if root == "" {
+			docs[i].root = "Document"
+			docs[i].body = docs[i].node
+			continue
+		}
+		docs[i].root = root
+	}
+}
+
+// Schema returns the structure inferred from the documents of files, which
+// the code is generated from. Warnings are reported to Options.Warn.
+func (g *Generator) Schema(files ...*ast.File) (schema.Schema, error) {
+	if g.opts.Naming != "" {
+		if _, err := naming.ParseStrategy(string(g.opts.Naming)); err != nil {
+			return schema.Schema{}, err
+		}
+	}
+
+	s, fileDiags := g.inferSchema(files)
+	if err := g.report(sortDiagnostics(files, fileDiags, nil)); err != nil {
+		return schema.Schema{}, err
+	}
+
+	return s, nil
+}
+
+// report passes the warnings of diags to Options.Warn and returns its
+// errors.
+func (g *Generator) report(diags diag.List) error {
+	if g.opts.Warn != nil {
+		for _, w := range diags.Warnings() {
+			g.opts.Warn(w)
+		}
+	}
+
+	return diags.Err()
+}
+
+// inferSchema walks every document of files and returns the objects in
+// output order: root objects in document order followed by the others
+// sorted by name, along with the problems found in each file.
+func (g *Generator) inferSchema(files []*ast.File) (schema.Schema, []diag.List) {
+	docs, fileDiags := g.documents(files)
+
+	// Collect the struct paths of every document before naming them so that
+	// collisions across documents are resolved as well
+	names := naming.NewRegistry(g.opts.Naming)
+	for _, doc := range docs {
+		names.Collect(doc.body, []string{doc.root})
+	}
+	names.Resolve()
+
+	// Process each document using Walk
+	objects := make(map[string]schema.Object)
+	for _, doc := range docs {
+		v := visitor.NewASTVisitor(objects, names, []string{doc.root}, visitor.Options{
+			File:    files[doc.file].Name,
+			Samples: g.opts.MergeDocuments,
+		})
+		ast.Walk(v, doc.body)
+		fileDiags[doc.file] = append(fileDiags[doc.file], v.Diagnostics()...)
+
+		if o, ok := objects[doc.root]; ok && o.Comment == "" {
+			o.Comment = rootComment(doc)
+			objects[doc.root] = o
+		}
+	}
+
+	var roots []string
+	for _, doc := range docs {
+		roots = append(roots, doc.root)
+	}
+	typeNullFields(objects, roots)
+
+	s := schema.Schema{Roots: []string{}, Keys: make(map[string]string)}
+
+	// Root objects first in order
+	for _, root := range roots {
+		if _, exists := objects[root]; exists && !slices.Contains(s.Roots, root) {
+			s.Roots = append(s.Roots, root)
+			s.Objects = append(s.Objects, objects[root])
+		}
+	}
+
+	// Roots named after the single key of their documents, unless another
+	// document of the root holds its keys at the top
+	for _, doc := range docs {
+		if !slices.Contains(s.Roots, doc.root) {
+			continue
+		}
+		if doc.body == ast.Node(doc.node) {
+			s.Keys[doc.root] = ""
+			continue
+		}
+		if _, exists := s.Keys[doc.root]; !exists {
+			s.Keys[doc.root] = naming.KeyName(doc.node.Body.(*ast.MappingNode).Values[0].Key)
 		}
 	}
+	for root, key := range s.Keys {
+		if key == "" {
+			delete(s.Keys, root)
+		}
+	}
+	if len(s.Keys) == 0 {
+		s.Keys = nil
+	}
 
-	// Generate other structs in sorted order
+	// Other objects in sorted order
 	var otherNames []string
-	for name := range g.structs {
-		isRoot := slices.Contains(rootNames, name)
-		if !isRoot {
+	for name := range objects {
+		if !slices.Contains(s.Roots, name) {
 			otherNames = append(otherNames, name)
 		}
 	}
 	sort.Strings(otherNames)
 
 	for _, name := range otherNames {
-		result.WriteString("\n")
-		_, err := result.WriteString(g.structs[name].String())
-		if err != nil {
-			return ""
+		s.Objects = append(s.Objects, objects[name])
+	}
+
+	return s, fileDiags
+}
+
+// sortDiagnostics returns the diagnostics found in each file of files,
+// along with the ones of located in the same file, ordered by file then by
+// position within the file.
+func sortDiagnostics(files []*ast.File, fileDiags []diag.List, located diag.List) diag.List {
+	placed := make([]bool, len(located))
+
+	var diags diag.List
+	for fi, list := range fileDiags {
+		list = slices.Clone(list)
+		for i := range list {
+			list[i].File = files[fi].Name
+		}
+		for i, d := range located {
+			if !placed[i] && d.File == files[fi].Name {
+				list = append(list, d)
+				placed[i] = true
+			}
+		}
+		list.Sort()
+		diags = append(diags, list...)
+	}
+
+	return diags
+}
+
+// typeNullFields gives the fields that are only null in some documents the
+// type found for the same path, relative to the root, in another document.
+// Fields merged into the same object have already been typed by the visitor.
+func typeNullFields(objects map[string]schema.Object, roots []string) {
+	types := make(map[string]schema.Type)
+	var nulls []nullField
+
+	var walk func(objectName, path string, seen []string)
+	walk = func(objectName, path string, seen []string) {
+		o, ok := objects[objectName]
+		if !ok || slices.Contains(seen, objectName) {
+			return
+		}
+		seen = append(seen, objectName)
+
+		for i, field := range o.Fields {
+			fieldPath := path + "." + field.Key
+			if field.Type.Kind == schema.KindNull {
+				nulls = append(nulls, nullField{objectName: objectName, index: i, path: fieldPath})
+				continue
+			}
+			if _, exists := types[fieldPath]; !exists {
+				types[fieldPath] = field.Type
+			}
+			walk(heldObject(field.Type), fieldPath, seen)
 		}
 	}
+	for _, root := range roots {
+		walk(root, "", nil)
+	}
 
-	return result.String()
+	for _, n := range nulls {
+		fieldType, ok := types[n.path]
+		if !ok {
+			continue
+		}
+		field := &objects[n.objectName].Fields[n.index]
+		field.Type = schema.Unify(field.Type, fieldType)
+	}
+}
+
+type nullField struct {
+	objectName string
+	index      int
+	path       string
+}
+
+// heldObject returns the name of the object held by values of t, directly
+// or as the elements of arrays, empty when there is none.
+func heldObject(t schema.Type) string {
+	for t.Kind == schema.KindArray {
+		t = t.Element()
+	}
+
+	return t.Object
+}
+
+// pointers returns the pointer policy of the options, see Options.Pointers.
+func (g *Generator) pointers() inference.PointerPolicy {
+	switch {
+	case g.opts.Pointers != "":
+		return g.opts.Pointers
+	case g.opts.MergeDocuments:
+		return inference.PointerOptional
+	default:
+		return inference.PointerAlways
+	}
+}
+
+func (g *Generator) structOptions() golang.Options {
+	return golang.Options{
+		Tags:             g.opts.Tags,
+		Cases:            g.opts.Cases,
+		OmitFlags:        g.opts.OmitFlags,
+		OmitZero:         g.opts.OmitZero,
+		Pointers:         g.pointers(),
+		OmitOptional:     g.opts.MergeDocuments,
+		ValidateRequired: g.opts.ValidateRequired,
+	}
 }
 
 func (g *Generator) determineDocumentName(doc *ast.DocumentNode, index int, totalDocs int) string {
@@ -80,15 +759,44 @@ func (g *Generator) determineDocumentName(doc *ast.DocumentNode, index int, tota
 			// Single key document - use the key name as struct name
 			firstMapping := mappingNode.Values[0]
 			keyNode := firstMapping.Key
-			keyValue := keyNode.String()
-			return codegen.Capitalize(keyValue)
+			keyValue := naming.KeyName(keyNode)
+			return ident.Exported(keyValue)
 		}
 	}
 
 	// Multiple keys, not a mapping, or no body - use default naming
+	return documentName(index, totalDocs)
+}
+
+// documentName returns the name of the root struct of the documents at
+// index that are not named after their key.
+func documentName(index int, totalDocs int) string {
 	if totalDocs == 1 {
 		return "Document"
-	} else {
-		return fmt.Sprintf("Document%d", index+1)
 	}
+
+	return fmt.Sprintf("Document%d", index+1)
+}
+
+// rootComment returns the comment of the key a document is named after, see
+// rootNode.
+func rootComment(doc document) string {
+	if doc.body == ast.Node(doc.node) {
+		return ""
+	}
+
+	return visitor.KeyComment(doc.node.Body.(*ast.MappingNode).Values[0])
+}
+
+// rootNode returns the node the root struct is generated from. A document
+// whose single key holds a mapping is named after that key, so the mapping
+// itself becomes the root struct.
+func rootNode(doc *ast.DocumentNode) ast.Node {
+	if mappingNode, ok := doc.Body.(*ast.MappingNode); ok && len(mappingNode.Values) == 1 {
+		if value, ok := mappingNode.Values[0].Value.(*ast.MappingNode); ok && len(value.Values) > 0 {
+			return value
+		}
+	}
+
+	return doc
 }
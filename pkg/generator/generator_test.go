@@ -1,11 +1,20 @@
 package generator
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/goccy/go-yaml/ast"
 	"github.com/goccy/go-yaml/parser"
+	"github.com/richerve/yaml2go/pkg/codegen"
+	"github.com/richerve/yaml2go/pkg/diag"
+	"github.com/richerve/yaml2go/pkg/ident"
+	"github.com/richerve/yaml2go/pkg/inference"
+	"github.com/richerve/yaml2go/pkg/naming"
 )
 
 // New is a simple constructor - no test needed
@@ -14,7 +23,7 @@ func TestGenerator_Generate(t *testing.T) {
 	tests := []struct {
 		name      string
 		yamlInput string
-		tagPrefix string
+		tags      []string
 		expected  string
 	}{
 		{
@@ -23,7 +32,7 @@ func TestGenerator_Generate(t *testing.T) {
 name: "john"
 age: 30
 `,
-			tagPrefix: "json",
+			tags: []string{"json"},
 			expected: `type Document struct {
 	Name *string ` + "`json:\"name\"`" + `
 	Age *int ` + "`json:\"age\"`" + `
@@ -37,7 +46,7 @@ user:
   name: "john"
   age: 30
 `,
-			tagPrefix: "json",
+			tags: []string{"json"},
 			expected: `type User struct {
 	Name *string ` + "`json:\"name\"`" + `
 	Age *int ` + "`json:\"age\"`" + `
@@ -51,7 +60,7 @@ name: "doc1"
 ---
 title: "doc2"
 `,
-			tagPrefix: "json",
+			tags: []string{"json"},
 			expected: `type Name struct {
 	Name *string ` + "`json:\"name\"`" + `
 }
@@ -70,7 +79,7 @@ user:
     age: 30
     active: true
 `,
-			tagPrefix: "yaml",
+			tags: []string{"yaml"},
 			expected: `type User struct {
 	Name *string ` + "`yaml:\"name\"`" + `
 	Profile Profile ` + "`yaml:\"profile\"`" + `
@@ -90,7 +99,7 @@ count: 42
 active: true
 metadata: {}
 `,
-			tagPrefix: "json",
+			tags: []string{"json"},
 			expected: `type Document struct {
 	Items []string ` + "`json:\"items\"`" + `
 	Count *int ` + "`json:\"count\"`" + `
@@ -107,13 +116,34 @@ empty_string: ""
 empty_array: []
 normal_field: "value"
 `,
-			tagPrefix: "json",
+			tags: []string{"json"},
 			expected: `type Document struct {
 	Name *string ` + "`json:\"name\"`" + `
 	EmptyString *string ` + "`json:\"empty_string,omitempty\"`" + `
 	EmptyArray []any ` + "`json:\"empty_array,omitempty\"`" + `
 	NormalField *string ` + "`json:\"normal_field\"`" + `
 }
+`,
+		},
+		{
+			name: "empty value in a later element",
+			yamlInput: `
+first: [{n: 0}, {n: 5}]
+second: [{m: 5}, {m: 0}]
+`,
+			tags: []string{"json"},
+			expected: `type Document struct {
+	First []FirstItem ` + "`json:\"first\"`" + `
+	Second []SecondItem ` + "`json:\"second\"`" + `
+}
+
+type FirstItem struct {
+	N *int ` + "`json:\"n,omitempty\"`" + `
+}
+
+type SecondItem struct {
+	M *int ` + "`json:\"m,omitempty\"`" + `
+}
 `,
 		},
 		{
@@ -124,74 +154,1664 @@ email_address: "john@example.com"
 is_active: true
 user_id: 123
 `,
-			tagPrefix: "json",
+			tags: []string{"json"},
+			expected: `type Document struct {
+	UserName *string ` + "`json:\"user_name\"`" + `
+	EmailAddress *string ` + "`json:\"email_address\"`" + `
+	IsActive *bool ` + "`json:\"is_active\"`" + `
+	UserID *int ` + "`json:\"user_id\"`" + `
+}
+`,
+		},
+		{
+			name: "kebab-case to PascalCase conversion",
+			yamlInput: `
+user-name: "john"
+email-address: "john@example.com"
+is-active: true
+`,
+			tags: []string{"yaml"},
+			expected: `type Document struct {
+	UserName *string ` + "`yaml:\"user-name\"`" + `
+	EmailAddress *string ` + "`yaml:\"email-address\"`" + `
+	IsActive *bool ` + "`yaml:\"is-active\"`" + `
+}
+`,
+		},
+		{
+			name: "multiple documents with single keys",
+			yamlInput: `
+config:
+  database: "localhost"
+  port: 5432
+---
+user:
+  name: "admin"
+  role: "administrator"
+`,
+			tags: []string{"json"},
+			expected: `type Config struct {
+	Database *string ` + "`json:\"database\"`" + `
+	Port *int ` + "`json:\"port\"`" + `
+}
+
+type User struct {
+	Name *string ` + "`json:\"name\"`" + `
+	Role *string ` + "`json:\"role\"`" + `
+}
+`,
+		},
+		{
+			name: "complex nested with arrays",
+			yamlInput: `
+users:
+  - name: "john"
+    age: 30
+  - name: "jane"
+    age: 25
+settings:
+  theme: "dark"
+  features: ["feature1", "feature2"]
+`,
+			tags: []string{"json"},
+			expected: `type Document struct {
+	Users []User ` + "`json:\"users\"`" + `
+	Settings Settings ` + "`json:\"settings\"`" + `
+}
+
+type Settings struct {
+	Theme *string ` + "`json:\"theme\"`" + `
+	Features []string ` + "`json:\"features\"`" + `
+}
+
+type User struct {
+	Name *string ` + "`json:\"name\"`" + `
+	Age *int ` + "`json:\"age\"`" + `
+}
+`,
+		},
+		{
+			name: "sequence elements with different fields",
+			yamlInput: `
+items:
+  - name: "a"
+    port: 1
+  - name: "b"
+    labels:
+      tier: "web"
+`,
+			tags: []string{"json"},
+			expected: `type Items struct {
+	Items []Item ` + "`json:\"items\"`" + `
+}
+
+type Item struct {
+	Name *string ` + "`json:\"name\"`" + `
+	Port *int ` + "`json:\"port\"`" + `
+	Labels Labels ` + "`json:\"labels\"`" + `
+}
+
+type Labels struct {
+	Tier *string ` + "`json:\"tier\"`" + `
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte(tt.yamlInput), 0)
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			gen := New(Options{Tags: tt.tags})
+			result, err := gen.Generate(file)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			// Normalize whitespace for comparison
+			normalizeWhitespace := func(s string) string {
+				return strings.TrimSpace(strings.ReplaceAll(s, "\t", "    "))
+			}
+
+			expectedNorm := normalizeWhitespace(tt.expected)
+			resultNorm := normalizeWhitespace(result)
+
+			if resultNorm != expectedNorm {
+				t.Errorf("Generate() result mismatch:\nExpected:\n%s\n\nGot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestGenerator_Generate_NamingStrategies(t *testing.T) {
+	yamlInput := `
+products:
+  settings:
+    charset: utf8
+server:
+  settings:
+    charset: latin1
+  proxy:
+    settings:
+      auto_increment: true
+`
+
+	tests := []struct {
+		name     string
+		strategy naming.Strategy
+		expected []string
+		structs  int
+	}{
+		{
+			name:     "parent strategy",
+			strategy: naming.Parent,
+			expected: []string{
+				"Settings ProductsSettings `json:\"settings\"`",
+				"Settings ServerSettings `json:\"settings\"`",
+				"Settings ProxySettings `json:\"settings\"`",
+				"type ProductsSettings struct",
+				"type ServerSettings struct",
+				"type ProxySettings struct",
+			},
+			structs: 7,
+		},
+		{
+			name:     "merge strategy",
+			strategy: naming.Merge,
+			expected: []string{
+				"Settings ProductsSettings `json:\"settings\"`",
+				"Settings ProxySettings `json:\"settings\"`",
+				"type ProductsSettings struct",
+				"type ProxySettings struct",
+			},
+			structs: 6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte(yamlInput), 0)
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			gen := New(Options{Tags: []string{"json"}, Naming: tt.strategy})
+			result, err := gen.Generate(file)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("Expected result to contain %s, got:\n%s", expected, result)
+				}
+			}
+			if count := strings.Count(result, "type "); count != tt.structs {
+				t.Errorf("Expected %d structs, got %d:\n%s", tt.structs, count, result)
+			}
+		})
+	}
+}
+
+func TestGenerator_Generate_Package(t *testing.T) {
+	tests := []struct {
+		name        string
+		yamlInput   string
+		pkgName     string
+		expected    string
+		expectError bool
+	}{
+		{
+			name: "complete file",
+			yamlInput: `
+server:
+  host: "localhost"
+  port: 8080
+`,
+			pkgName: "config",
+			expected: `// Code generated by yaml2go. DO NOT EDIT.
+
+package config
+
+type Server struct {
+	Host *string ` + "`json:\"host\"`" + `
+	Port *int    ` + "`json:\"port\"`" + `
+}
+`,
+		},
+		{
+			name:      "empty document",
+			yamlInput: ``,
+			pkgName:   "config",
+			expected: `// Code generated by yaml2go. DO NOT EDIT.
+
+package config
+`,
+		},
+		{
+			name:        "invalid package name",
+			yamlInput:   `name: "test"`,
+			pkgName:     "my-config",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte(tt.yamlInput), 0)
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			gen := New(Options{Tags: []string{"json"}, Package: tt.pkgName})
+			result, err := gen.Generate(file)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if result != tt.expected {
+				t.Errorf("Generate() result mismatch:\nExpected:\n%s\n\nGot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestGenerator_Generate_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		yamlInput string
+		expected  string
+	}{
+		{
+			name: "errors sorted by position",
+			yamlInput: `
+server:
+  note: *missing
+  ratio: *other
+`,
+			expected: `3:9: alias *missing refers to an undefined anchor
+4:10: alias *other refers to an undefined anchor`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte(tt.yamlInput), 0)
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			gen := New(Options{Tags: []string{"json"}})
+			result, err := gen.Generate(file)
+			if err == nil {
+				t.Fatalf("Expected an error but got none, result:\n%s", result)
+			}
+			if result != "" {
+				t.Errorf("Expected no result on error, got:\n%s", result)
+			}
+
+			var diags diag.List
+			if !errors.As(err, &diags) {
+				t.Fatalf("Expected a diag.List error, got %T", err)
+			}
+			if err.Error() != tt.expected {
+				t.Errorf("Generate() error = %v, want %v", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenerator_Generate_Options(t *testing.T) {
+	yamlInput := `
+name: "test"
+empty: ""
+`
+
+	tests := []struct {
+		name        string
+		opts        Options
+		expected    string
+		expectError bool
+	}{
+		{
+			name: "default options",
+			opts: DefaultOptions(),
+			expected: `type Document struct {
+	Name *string ` + "`json:\"name\"`" + `
+	Empty *string ` + "`json:\"empty,omitempty\"`" + `
+}
+`,
+		},
+		{
+			name: "zero options",
+			opts: Options{},
+			expected: `type Document struct {
+	Name *string
+	Empty *string
+}
+`,
+		},
+		{
+			name: "yaml tags with omitzero",
+			opts: Options{Tags: []string{"yaml"}, OmitZero: true},
+			expected: `type Document struct {
+	Name *string ` + "`yaml:\"name\"`" + `
+	Empty *string ` + "`yaml:\"empty,omitzero\"`" + `
+}
+`,
+		},
+		{
+			name:        "unknown naming strategy",
+			opts:        Options{Naming: "last"},
+			expectError: true,
+		},
+		{
+			name:        "unknown pointer policy",
+			opts:        Options{Pointers: "sometimes"},
+			expectError: true,
+		},
+		{
+			name:        "invalid tag key",
+			opts:        Options{Tags: []string{"json", "my tag"}},
+			expectError: true,
+		},
+		{
+			name:        "duplicate tag key",
+			opts:        Options{Tags: []string{"json", "json"}},
+			expectError: true,
+		},
+		{
+			name:        "unknown case",
+			opts:        Options{Tags: []string{"json"}, Cases: map[string]ident.Case{"json": "title"}},
+			expectError: true,
+		},
+		{
+			name:        "case for a tag that is not generated",
+			opts:        Options{Tags: []string{"json"}, Cases: map[string]ident.Case{"yaml": ident.Snake}},
+			expectError: true,
+		},
+		{
+			name:        "unknown omit flag",
+			opts:        Options{Tags: []string{"json"}, OmitFlags: map[string]string{"json": "omitnil"}},
+			expectError: true,
+		},
+		{
+			name:        "omit flag for a tag that is not generated",
+			opts:        Options{Tags: []string{"json"}, OmitFlags: map[string]string{"yaml": "omitzero"}},
+			expectError: true,
+		},
+		{
+			name:        "validate tag along with the required fields",
+			opts:        Options{Tags: []string{"json", "validate"}, ValidateRequired: true},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte(yamlInput), 0)
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			result, err := New(tt.opts).Generate(file)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if result != tt.expected {
+				t.Errorf("Generate() result mismatch:\nExpected:\n%s\n\nGot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestGenerator_Generate_PointerPolicies(t *testing.T) {
+	yamlInput := `
+name: "test"
+empty: ""
+nothing: null
+items:
+  - id: 1
+    label: "a"
+  - id: 2
+`
+
+	tests := []struct {
+		name     string
+		policy   inference.PointerPolicy
+		expected string
+	}{
+		{
+			name:   "always",
+			policy: inference.PointerAlways,
+			expected: `type Document struct {
+	Name *string ` + "`json:\"name\"`" + `
+	Empty *string ` + "`json:\"empty,omitempty\"`" + `
+	Nothing any ` + "`json:\"nothing\"`" + `
+	Items []Item ` + "`json:\"items\"`" + `
+}
+
+type Item struct {
+	ID *int ` + "`json:\"id\"`" + `
+	Label *string ` + "`json:\"label\"`" + `
+}
+`,
+		},
+		{
+			name:   "never",
+			policy: inference.PointerNever,
+			expected: `type Document struct {
+	Name string ` + "`json:\"name\"`" + `
+	Empty string ` + "`json:\"empty,omitempty\"`" + `
+	Nothing any ` + "`json:\"nothing\"`" + `
+	Items []Item ` + "`json:\"items\"`" + `
+}
+
+type Item struct {
+	ID int ` + "`json:\"id\"`" + `
+	Label string ` + "`json:\"label\"`" + `
+}
+`,
+		},
+		{
+			name:   "optional",
+			policy: inference.PointerOptional,
+			expected: `type Document struct {
+	Name string ` + "`json:\"name\"`" + `
+	Empty *string ` + "`json:\"empty,omitempty\"`" + `
+	Nothing any ` + "`json:\"nothing\"`" + `
+	Items []Item ` + "`json:\"items\"`" + `
+}
+
+type Item struct {
+	ID int ` + "`json:\"id\"`" + `
+	Label *string ` + "`json:\"label\"`" + `
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte(yamlInput), 0)
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			result, err := New(Options{Tags: []string{"json"}, Pointers: tt.policy}).Generate(file)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if result != tt.expected {
+				t.Errorf("Generate() result mismatch:\nExpected:\n%s\n\nGot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestGenerator_Generate_NullValues(t *testing.T) {
+	tests := []struct {
+		name      string
+		yamlInput string
+		pointers  inference.PointerPolicy
+		expected  string
+	}{
+		{
+			name: "typed from another document",
+			yamlInput: `
+port: null
+name: "a"
+---
+port: 5
+name: "b"
+`,
+			expected: `type Document1 struct {
+	Port *int ` + "`json:\"port\"`" + `
+	Name *string ` + "`json:\"name\"`" + `
+}
+
+type Document2 struct {
+	Port *int ` + "`json:\"port\"`" + `
+	Name *string ` + "`json:\"name\"`" + `
+}
+`,
+		},
+		{
+			name: "typed from another sequence element",
+			yamlInput: `
+name: "list"
+items:
+  - size: 1
+    owner: null
+  - size: 2
+    owner:
+      name: "x"
+`,
+			pointers: inference.PointerOptional,
+			expected: `type Document struct {
+	Name string ` + "`json:\"name\"`" + `
+	Items []Item ` + "`json:\"items\"`" + `
+}
+
+type Item struct {
+	Size int ` + "`json:\"size\"`" + `
+	Owner *Owner ` + "`json:\"owner\"`" + `
+}
+
+type Owner struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+`,
+		},
+		{
+			name: "null sequence elements",
+			yamlInput: `
+list: [null, 1]
+servers:
+  - host: a
+  - null
+`,
+			pointers: inference.PointerOptional,
+			expected: `type Document struct {
+	List []*int ` + "`json:\"list\"`" + `
+	Servers []*Server ` + "`json:\"servers\"`" + `
+}
+
+type Server struct {
+	Host string ` + "`json:\"host\"`" + `
+}
+`,
+		},
+		{
+			name: "unknown without a concrete value",
+			yamlInput: `
+name: "list"
+items:
+  - value: null
+  - value: ~
+`,
+			expected: `type Document struct {
+	Name *string ` + "`json:\"name\"`" + `
+	Items []Item ` + "`json:\"items\"`" + `
+}
+
+type Item struct {
+	Value any ` + "`json:\"value\"`" + `
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte(tt.yamlInput), 0)
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			result, err := New(Options{Tags: []string{"json"}, Pointers: tt.pointers}).Generate(file)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if result != tt.expected {
+				t.Errorf("Generate() result mismatch:\nExpected:\n%s\n\nGot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestGenerator_Generate_HeterogeneousSequences(t *testing.T) {
+	yamlInput := `
+ratios: [1, 2.5]
+mixed: [1, "a"]
+items:
+  - id: 1
+    weight: 2
+  - id: 2
+    weight: 2.5
+    tags: ["x"]
+`
+
+	expected := `type Document struct {
+	Ratios []float64 ` + "`json:\"ratios\"`" + `
+	Mixed []any ` + "`json:\"mixed\"`" + `
+	Items []Item ` + "`json:\"items\"`" + `
+}
+
+type Item struct {
+	ID int ` + "`json:\"id\"`" + `
+	Weight float64 ` + "`json:\"weight\"`" + `
+	Tags []string ` + "`json:\"tags\"`" + `
+}
+`
+
+	file, err := parser.ParseBytes([]byte(yamlInput), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	result, err := New(Options{Tags: []string{"json"}, Pointers: inference.PointerNever}).Generate(file)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result != expected {
+		t.Errorf("Generate() result mismatch:\nExpected:\n%s\n\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerator_Generate_AnchorsAndBlockScalars(t *testing.T) {
+	yamlInput := `
+defaults: &defaults
+  adapter: postgres
+  pool:
+    size: 5
+development:
+  <<: *defaults
+  database: dev
+backup: *defaults
+notes: |
+  multi
+  line
+summary: >
+  folded
+`
+
+	expected := `type Document struct {
+	Defaults Defaults ` + "`json:\"defaults\"`" + `
+	Development Development ` + "`json:\"development\"`" + `
+	Backup Defaults ` + "`json:\"backup\"`" + `
+	Notes string ` + "`json:\"notes\"`" + `
+	Summary string ` + "`json:\"summary\"`" + `
+}
+
+type Defaults struct {
+	Adapter string ` + "`json:\"adapter\"`" + `
+	Pool Pool ` + "`json:\"pool\"`" + `
+}
+
+type Development struct {
+	Adapter string ` + "`json:\"adapter\"`" + `
+	Pool Pool ` + "`json:\"pool\"`" + `
+	Database string ` + "`json:\"database\"`" + `
+}
+
+type Pool struct {
+	Size int ` + "`json:\"size\"`" + `
+}
+`
+
+	file, err := parser.ParseBytes([]byte(yamlInput), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	result, err := New(Options{Tags: []string{"json"}, Pointers: inference.PointerNever}).Generate(file)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result != expected {
+		t.Errorf("Generate() result mismatch:\nExpected:\n%s\n\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerator_Generate_Tags(t *testing.T) {
+	yamlInput := `
+port: !!str 8080
+created: !!timestamp 2024-01-01
+blob: !!binary aGVsbG8=
+members: !!set {a, b}
+`
+
+	expected := `// Code generated by yaml2go. DO NOT EDIT.
+
+package config
+
+import (
+	"time"
+)
+
+type Document struct {
+	Port    *string             ` + "`json:\"port\"`" + `
+	Created *time.Time          ` + "`json:\"created\"`" + `
+	Blob    []byte              ` + "`json:\"blob\"`" + `
+	Members map[string]struct{} ` + "`json:\"members\"`" + `
+}
+`
+
+	file, err := parser.ParseBytes([]byte(yamlInput), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	result, err := New(Options{Tags: []string{"json"}, Package: "config"}).Generate(file)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result != expected {
+		t.Errorf("Generate() result mismatch:\nExpected:\n%s\n\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerator_Generate_Comments(t *testing.T) {
+	tests := []struct {
+		name      string
+		yamlInput string
+		expected  string
+	}{
+		{
+			name: "field and struct comments",
+			yamlInput: `
+# Service name
+name: api
+# HTTP server
+server: # listener
+  port: 80 # default port
+`,
+			expected: `type Document struct {
+	// Service name
+	Name *string ` + "`json:\"name\"`" + `
+	// HTTP server
+	// listener
+	Server Server ` + "`json:\"server\"`" + `
+}
+
+// HTTP server
+// listener
+type Server struct {
+	// default port
+	Port *int ` + "`json:\"port\"`" + `
+}
+`,
+		},
+		{
+			name: "single key root",
+			yamlInput: `
+# The configuration
+config:
+  debug: true
+`,
+			expected: `// The configuration
+type Config struct {
+	Debug *bool ` + "`json:\"debug\"`" + `
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte(tt.yamlInput), parser.ParseComments)
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			result, err := New(Options{Tags: []string{"json"}}).Generate(file)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if result != tt.expected {
+				t.Errorf("Generate() result mismatch:\nExpected:\n%s\n\nGot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestGenerator_Generate_DuplicateFieldNames(t *testing.T) {
+	yamlInput := `
+foo_bar: 1
+foo-bar: 2
+items:
+  - fooBar: 3
+    foo_bar: 4
+  - fooBar: 5
+    foo_bar: 6
+`
+
+	expected := `type Document struct {
+	FooBar *int ` + "`json:\"foo_bar\"`" + `
+	FooBar2 *int ` + "`json:\"foo-bar\"`" + `
+	Items []Item ` + "`json:\"items\"`" + `
+}
+
+type Item struct {
+	FooBar *int ` + "`json:\"fooBar\"`" + `
+	FooBar2 *int ` + "`json:\"foo_bar\"`" + `
+}
+`
+
+	expectedWarnings := []string{
+		`3:1: Document.foo-bar: warning: key "foo-bar" produces field name FooBar already used by key "foo_bar" in struct Document, renamed to FooBar2`,
+		`6:5: Document.items.item.foo_bar: warning: key "foo_bar" produces field name FooBar already used by key "fooBar" in struct Item, renamed to FooBar2`,
+	}
+
+	file, err := parser.ParseBytes([]byte(yamlInput), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	var warnings []string
+	opts := Options{
+		Tags: []string{"json"},
+		Warn: func(d diag.Diagnostic) {
+			warnings = append(warnings, d.Error())
+		},
+	}
+
+	result, err := New(opts).Generate(file)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result != expected {
+		t.Errorf("Generate() result mismatch:\nExpected:\n%s\n\nGot:\n%s", expected, result)
+	}
+
+	if strings.Join(warnings, "\n") != strings.Join(expectedWarnings, "\n") {
+		t.Errorf("Warnings = %v, want %v", warnings, expectedWarnings)
+	}
+}
+
+func TestGenerator_Generate_OmitFlagsPerTag(t *testing.T) {
+	yamlInput := `
+name: ""
+`
+
+	tests := []struct {
+		name      string
+		omitZero  bool
+		omitFlags map[string]string
+		expected  string
+	}{
+		{
+			name:     "omitempty",
+			expected: "`json:\"name,omitempty\" yaml:\"name,omitempty\" mapstructure:\"name,omitempty\"`",
+		},
+		{
+			name:     "omitzero on every tag",
+			omitZero: true,
+			expected: "`json:\"name,omitzero\" yaml:\"name,omitzero\" mapstructure:\"name,omitzero\"`",
+		},
+		{
+			name:      "omitzero for json only",
+			omitFlags: map[string]string{"json": "omitzero"},
+			expected:  "`json:\"name,omitzero\" yaml:\"name,omitempty\" mapstructure:\"name,omitempty\"`",
+		},
+		{
+			name:      "flags of their own override omitzero",
+			omitZero:  true,
+			omitFlags: map[string]string{"mapstructure": "omitempty"},
+			expected:  "`json:\"name,omitzero\" yaml:\"name,omitzero\" mapstructure:\"name,omitempty\"`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte(yamlInput), 0)
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			opts := Options{Tags: []string{"json", "yaml", "mapstructure"}, OmitZero: tt.omitZero, OmitFlags: tt.omitFlags}
+			result, err := New(opts).Generate(file)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !strings.Contains(result, tt.expected) {
+				t.Errorf("Expected result to contain %s, got:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestGenerator_Generate_TagCases(t *testing.T) {
+	yamlInput := `
+max_connections: 10
+server:
+  listenAddress: ":80"
+`
+
+	expected := `type Document struct {
+	MaxConnections *int ` + "`json:\"maxConnections\" yaml:\"max_connections\" env:\"MAX_CONNECTIONS\"`" + `
+	Server Server ` + "`json:\"server\" yaml:\"server\" env:\"SERVER\"`" + `
+}
+
+type Server struct {
+	ListenAddress *string ` + "`json:\"listenAddress\" yaml:\"listenAddress\" env:\"LISTEN_ADDRESS\"`" + `
+}
+`
+
+	file, err := parser.ParseBytes([]byte(yamlInput), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	opts := Options{
+		Tags:  []string{"json", "yaml", "env"},
+		Cases: map[string]ident.Case{"json": ident.Camel, "yaml": ident.Keep, "env": ident.ScreamingSnake},
+	}
+	result, err := New(opts).Generate(file)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result != expected {
+		t.Errorf("Generate() result mismatch:\nExpected:\n%s\n\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerator_Generate_TagCaseCollisions(t *testing.T) {
+	yamlInput := `
+foo_bar: 1
+fooBar: 2
+`
+
+	expectedWarnings := []string{
+		`3:1: Document.fooBar: warning: key "fooBar" produces field name FooBar already used by key "foo_bar" in struct Document, renamed to FooBar2`,
+		`3:1: Document.fooBar: warning: key "fooBar" produces json tag "foo_bar" already used by key "foo_bar" in struct Document`,
+	}
+
+	file, err := parser.ParseBytes([]byte(yamlInput), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	var warnings []string
+	opts := Options{
+		Tags:  []string{"json", "yaml"},
+		Cases: map[string]ident.Case{"json": ident.Snake},
+		Warn: func(d diag.Diagnostic) {
+			warnings = append(warnings, d.Error())
+		},
+	}
+
+	if _, err := New(opts).Generate(file); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if strings.Join(warnings, "\n") != strings.Join(expectedWarnings, "\n") {
+		t.Errorf("Warnings = %v, want %v", warnings, expectedWarnings)
+	}
+}
+
+func TestGenerator_Generate_MultipleFiles(t *testing.T) {
+	inputs := map[string]string{
+		"dev.yaml": `
+name: app
+port: 80
+`,
+		"prod.yaml": `
+name: app
+port: null
+replicas: 3
+---
+extra: true
+`,
+	}
+
+	expected := `type Document1 struct {
+	Name string ` + "`json:\"name\"`" + `
+	Port *int ` + "`json:\"port\"`" + `
+	Replicas *int ` + "`json:\"replicas\"`" + `
+}
+
+type Extra struct {
+	Extra bool ` + "`json:\"extra\"`" + `
+}
+`
+
+	var files []*ast.File
+	for _, name := range []string{"dev.yaml", "prod.yaml"} {
+		file, err := parser.ParseBytes([]byte(inputs[name]), 0)
+		if err != nil {
+			t.Fatalf("Failed to parse YAML: %v", err)
+		}
+		file.Name = name
+		files = append(files, file)
+	}
+
+	// The single document of dev.yaml is numbered as prod.yaml holds two
+	result, err := New(Options{Tags: []string{"json"}, Pointers: inference.PointerOptional}).Generate(files...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result != expected {
+		t.Errorf("Generate() result mismatch:\nExpected:\n%s\n\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerator_Generate_MultipleFilesUnion(t *testing.T) {
+	tests := []struct {
+		name     string
+		inputs   []string
+		expected string
+	}{
+		{
+			name:   "several keys",
+			inputs: []string{"name: app\nport: 80\n", "name: app\ndebug: true\n"},
+			expected: `type Document struct {
+	Name string ` + "`json:\"name\"`" + `
+	Port *int ` + "`json:\"port\"`" + `
+	Debug *bool ` + "`json:\"debug\"`" + `
+}
+`,
+		},
+		{
+			name:   "single key in one of the files",
+			inputs: []string{"port: 80\n", "port: 8080\ndebug: true\n"},
+			expected: `type Document struct {
+	Port int ` + "`json:\"port\"`" + `
+	Debug *bool ` + "`json:\"debug\"`" + `
+}
+`,
+		},
+		{
+			name:   "different single keys",
+			inputs: []string{"server:\n  port: 80\n", "client:\n  port: 80\n"},
+			expected: `type Document struct {
+	Server Server ` + "`json:\"server\"`" + `
+	Client Client ` + "`json:\"client\"`" + `
+}
+
+type Client struct {
+	Port int ` + "`json:\"port\"`" + `
+}
+
+type Server struct {
+	Port int ` + "`json:\"port\"`" + `
+}
+`,
+		},
+		{
+			name:   "same single key",
+			inputs: []string{"server:\n  port: 80\n", "server:\n  host: a\n"},
+			expected: `type Server struct {
+	Port *int ` + "`json:\"port\"`" + `
+	Host *string ` + "`json:\"host\"`" + `
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var files []*ast.File
+			for _, input := range tt.inputs {
+				file, err := parser.ParseBytes([]byte(input), 0)
+				if err != nil {
+					t.Fatalf("Failed to parse YAML: %v", err)
+				}
+				files = append(files, file)
+			}
+
+			result, err := New(Options{Tags: []string{"json"}, Pointers: inference.PointerOptional}).Generate(files...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if result != tt.expected {
+				t.Errorf("Generate() result mismatch:\nExpected:\n%s\n\nGot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestGenerator_Generate_MultipleFilesDiagnostics(t *testing.T) {
+	var files []*ast.File
+	for _, name := range []string{"b.yaml", "a.yaml"} {
+		file, err := parser.ParseBytes([]byte("x: 1\nurl: *missing\n"), 0)
+		if err != nil {
+			t.Fatalf("Failed to parse YAML: %v", err)
+		}
+		file.Name = name
+		files = append(files, file)
+	}
+
+	_, err := New(DefaultOptions()).Generate(files...)
+
+	// Diagnostics follow the order of the files
+	expected := "b.yaml:2:6: alias *missing refers to an undefined anchor\n" +
+		"a.yaml:2:6: alias *missing refers to an undefined anchor"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Generate() error = %v, want %v", err, expected)
+	}
+}
+
+func TestGenerator_GenerateFiles(t *testing.T) {
+	yamlInput := `
+server:
+  host: localhost
+  tls:
+    cert: server.pem
+---
+clients:
+  - name: web
+    tls:
+      cert: client.pem
+`
+
+	tests := []struct {
+		name     string
+		split    Split
+		expected map[string][]string
+	}{
+		{
+			name:  "single file",
+			split: "",
+			expected: map[string][]string{
+				"types_gen.go": {"Server", "Clients", "Client", "TLS"},
+			},
+		},
+		{
+			name:  "one file per document",
+			split: SplitDocument,
+			expected: map[string][]string{
+				"server_gen.go":  {"Server", "TLS"},
+				"clients_gen.go": {"Clients", "Client"},
+			},
+		},
+		{
+			name:  "one file per struct",
+			split: SplitStruct,
+			expected: map[string][]string{
+				"server_gen.go":  {"Server"},
+				"clients_gen.go": {"Clients"},
+				"client_gen.go":  {"Client"},
+				"tls_gen.go":     {"TLS"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte(yamlInput), 0)
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			// TLS is shared by both documents and belongs to the first one
+			opts := DefaultOptions()
+			opts.Naming = naming.Merge
+			opts.Package = "config"
+			opts.Split = tt.split
+			files, err := New(opts).GenerateFiles(file)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(files) != len(tt.expected) {
+				t.Fatalf("Expected %d files, got %d", len(tt.expected), len(files))
+			}
+			for _, f := range files {
+				structs, ok := tt.expected[f.Name]
+				if !ok {
+					t.Errorf("Unexpected file %s", f.Name)
+					continue
+				}
+				source := string(f.Source)
+				if !strings.HasPrefix(source, codegen.GeneratedHeader+"\n\npackage config\n") {
+					t.Errorf("Expected %s to be a complete file, got:\n%s", f.Name, source)
+				}
+				if strings.Count(source, "type ") != len(structs) {
+					t.Errorf("Expected %d structs in %s, got:\n%s", len(structs), f.Name, source)
+				}
+				for _, name := range structs {
+					if !strings.Contains(source, "type "+name+" struct") {
+						t.Errorf("Expected struct %s in %s, got:\n%s", name, f.Name, source)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGenerator_GenerateFiles_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{
+			name: "no package",
+			opts: Options{Split: SplitStruct},
+		},
+		{
+			name: "unknown split",
+			opts: Options{Package: "config", Split: "file"},
+		},
+		{
+			name: "JSON Schema format",
+			opts: Options{Package: "config", Format: FormatJSONSchema},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte("name: test\n"), 0)
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			if _, err := New(tt.opts).GenerateFiles(file); err == nil {
+				t.Errorf("Expected an error but got none")
+			}
+		})
+	}
+}
+
+func TestGenerator_Generate_Verify(t *testing.T) {
+	yamlInput := `
+started: !!timestamp 2024-01-02
+server:
+  ports: [80, 443]
+  tls:
+    cert: server.pem
+items:
+  - name: a
+    tls: {cert: a.pem}
+"a\"b": quoted
+"a` + "`" + `b": backtick
+"-": dash
+`
+
+	file, err := parser.ParseBytes([]byte(yamlInput), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	// Keys that can't be written in a tag are left out rather than
+	// breaking the generated file
+	var warnings []string
+	opts := DefaultOptions()
+	opts.Package = "config"
+	opts.Verify = true
+	opts.Values = true
+	opts.Warn = func(d diag.Diagnostic) {
+		if strings.Contains(d.Message, "struct tag") {
+			warnings = append(warnings, d.Error())
+		}
+	}
+	result, err := New(opts).Generate(file)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(warnings) != 2 {
+		t.Errorf("Expected a warning for each key left out, got %v", warnings)
+	}
+	if !strings.Contains(result, "`json:\"-,\"`") {
+		t.Errorf("Expected the - key to be tagged -, got:\n%s", result)
+	}
+}
+
+func TestVerifyCode(t *testing.T) {
+	config := func(nestedType string) []codegen.StructDef {
+		return []codegen.StructDef{
+			{
+				Name:   "Config",
+				Source: codegen.Source{File: "app.yaml", Path: "Config", Line: 1, Column: 7},
+				Fields: []codegen.FieldDef{
+					{
+						Name:   "name",
+						Type:   "*string",
+						Source: codegen.Source{File: "app.yaml", Path: "Config.name", Line: 2, Column: 3},
+					},
+					{
+						Name:   "nested",
+						Type:   nestedType,
+						Source: codegen.Source{File: "app.yaml", Path: "Config.nested", Line: 3, Column: 3},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		structs  []codegen.StructDef
+		expected string
+	}{
+		{
+			name:     "type error",
+			structs:  config("NestedStruct"),
+			expected: "app.yaml:3:3: Config.nested: generated code doesn't compile: undefined: NestedStruct",
+		},
+		{
+			name:     "syntax error",
+			structs:  config("map[string"),
+			expected: "app.yaml:3:3: Config.nested: generated code doesn't compile: expected ']', found newline",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyCode(tt.structs, nil)
+
+			var diags diag.List
+			if !errors.As(err, &diags) {
+				t.Fatalf("Expected a diag.List, got %v", err)
+			}
+			if len(diags) == 0 || !strings.HasPrefix(diags[0].Error(), tt.expected) {
+				t.Errorf("verifyCode() = %v, want %v", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenerator_Generate_Values(t *testing.T) {
+	yamlInput := `
+name: app
+ports: [80, 443]
+---
+server:
+  host: localhost
+  tls:
+    cert: null
+`
+
+	expected := `type Document1 struct {
+	Name *string ` + "`json:\"name\"`" + `
+	Ports []int ` + "`json:\"ports\"`" + `
+}
+
+type Server struct {
+	Host *string ` + "`json:\"host\"`" + `
+	TLS TLS ` + "`json:\"tls\"`" + `
+}
+
+type TLS struct {
+	Cert any ` + "`json:\"cert\"`" + `
+}
+
+// Document1Value holds the data of document 1 of the YAML.
+var Document1Value = Document1{
+	Name: ptr("app"),
+	Ports: []int{80, 443},
+}
+
+// ServerValue holds the data of document 2 of the YAML.
+var ServerValue = Server{
+	Host: ptr("localhost"),
+	TLS: TLS{},
+}
+
+// ptr returns a pointer to v, for the pointer fields of the values.
+func ptr[T any](v T) *T {
+	return &v
+}
+`
+
+	file, err := parser.ParseBytes([]byte(yamlInput), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	opts := DefaultOptions()
+	opts.Values = true
+	opts.Verify = true
+	result, err := New(opts).Generate(file)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result != expected {
+		t.Errorf("Generate() result mismatch:\nExpected:\n%s\n\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerator_Generate_ValuesNames(t *testing.T) {
+	inputs := []struct {
+		name string
+		yaml string
+	}{
+		{name: "configs/dev.yaml", yaml: "name: app\nport: 80\n"},
+		{name: "configs/prod.yaml", yaml: "name: app\nport: 8080\n---\nextra_value: 1\nother: 2\n"},
+	}
+
+	var files []*ast.File
+	for _, input := range inputs {
+		file, err := parser.ParseBytes([]byte(input.yaml), 0)
+		if err != nil {
+			t.Fatalf("Failed to parse YAML: %v", err)
+		}
+		file.Name = input.name
+		files = append(files, file)
+	}
+
+	opts := DefaultOptions()
+	opts.Values = true
+	result, err := New(opts).Generate(files...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The shared root struct is told apart by file, a value never takes the
+	// name of a struct
+	for _, expected := range []string{
+		"// DevDocument1Value holds the data of configs/dev.yaml.\nvar DevDocument1Value = Document1{",
+		"// ProdDocument1Value holds the data of document 1 of configs/prod.yaml.\nvar ProdDocument1Value = Document1{",
+		"var Document2Value = Document2{",
+	} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected %q in:\n%s", expected, result)
+		}
+	}
+
+	file, err := parser.ParseBytes([]byte("document_value: 1\nother: 2\n"), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+	result, err = New(opts).Generate(file)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(result, "var DocumentValue = Document{") {
+		t.Errorf("Expected DocumentValue in:\n%s", result)
+	}
+
+	single, err := parser.ParseBytes([]byte("config_value:\n  a: 1\n---\nconfig_value:\n  a: 2\n"), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+	result, err = New(opts).Generate(single)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(result, "var ConfigValueValue = ConfigValue{") || !strings.Contains(result, "var ConfigValueValue2 = ConfigValue{") {
+		t.Errorf("Expected numbered values in:\n%s", result)
+	}
+}
+
+func TestGenerator_Generate_ValuesErrors(t *testing.T) {
+	file, err := parser.ParseBytes([]byte("name: app\nsize: 18446744073709551615\n"), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+	file.Name = "config.yaml"
+
+	opts := DefaultOptions()
+	opts.Values = true
+	_, err = New(opts).Generate(file)
+
+	var diags diag.List
+	if !errors.As(err, &diags) {
+		t.Fatalf("Expected a diag.List, got %v", err)
+	}
+	expected := "config.yaml:2:7: Document.size: value 18446744073709551615 overflows int"
+	if len(diags) != 1 || diags[0].Error() != expected {
+		t.Errorf("Generate() error = %v, want %v", err, expected)
+	}
+}
+
+func TestGenerator_GenerateFiles_Values(t *testing.T) {
+	yamlInput := `
+server:
+  host: localhost
+---
+client:
+  name: web
+`
+	file, err := parser.ParseBytes([]byte(yamlInput), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	opts := DefaultOptions()
+	opts.Package = "config"
+	opts.Values = true
+	opts.Split = SplitDocument
+	files, err := New(opts).GenerateFiles(file)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	if strings.Join(names, ",") != "server_gen.go,client_gen.go,values_gen.go" {
+		t.Fatalf("GenerateFiles() = %v, want server_gen.go, client_gen.go and values_gen.go", names)
+	}
+
+	// The values share the helper of their file
+	values := string(files[2].Source)
+	for _, expected := range []string{"var ServerValue = Server{", "var ClientValue = Client{", "func ptr[T any]"} {
+		if strings.Count(values, expected) != 1 {
+			t.Errorf("Expected %q once in values_gen.go, got:\n%s", expected, values)
+		}
+	}
+	for _, f := range files[:2] {
+		if strings.Contains(string(f.Source), "var ") {
+			t.Errorf("Expected no values in %s, got:\n%s", f.Name, f.Source)
+		}
+	}
+}
+
+func TestGenerator_Generate_MergeDocuments(t *testing.T) {
+	tests := []struct {
+		name     string
+		inputs   []string
+		opts     Options
+		expected string
+	}{
+		{
+			name: "samples across documents and files",
+			inputs: []string{
+				"name: app\nport: 80\ntls:\n  cert: a.pem\nlimits: {cpu: 1}\n---\nname: api\nport: 8080.5\nlimits: {cpu: 2, mem: 512}\n",
+				"name: db\nport: 5432\nreplicas: 3\nlimits: {cpu: 4}\n",
+			},
+			opts: Options{Tags: []string{"json", "yaml"}, Pointers: inference.PointerOptional, MergeDocuments: true},
 			expected: `type Document struct {
-	UserName *string ` + "`json:\"user_name\"`" + `
-	EmailAddress *string ` + "`json:\"email_address\"`" + `
-	IsActive *bool ` + "`json:\"is_active\"`" + `
-	UserId *int ` + "`json:\"user_id\"`" + `
+	Name string ` + "`json:\"name\" yaml:\"name\"`" + `
+	Port float64 ` + "`json:\"port\" yaml:\"port\"`" + `
+	TLS *TLS ` + "`json:\"tls,omitempty\" yaml:\"tls,omitempty\"`" + `
+	Limits Limits ` + "`json:\"limits\" yaml:\"limits\"`" + `
+	Replicas *int ` + "`json:\"replicas,omitempty\" yaml:\"replicas,omitempty\"`" + `
+}
+
+type Limits struct {
+	CPU int ` + "`json:\"cpu\" yaml:\"cpu\"`" + `
+	Mem *int ` + "`json:\"mem,omitempty\" yaml:\"mem,omitempty\"`" + `
+}
+
+type TLS struct {
+	Cert string ` + "`json:\"cert\" yaml:\"cert\"`" + `
 }
 `,
 		},
 		{
-			name: "kebab-case to PascalCase conversion",
-			yamlInput: `
-user-name: "john"
-email-address: "john@example.com"
-is-active: true
-`,
-			tagPrefix: "yaml",
-			expected: `type Document struct {
-	UserName *string ` + "`yaml:\"user-name\"`" + `
-	EmailAddress *string ` + "`yaml:\"email-address\"`" + `
-	IsActive *bool ` + "`yaml:\"is-active\"`" + `
+			name:   "samples sharing their single key",
+			inputs: []string{"server:\n  host: a\n---\nserver:\n  host: b\n  port: 80\n"},
+			opts:   Options{Tags: []string{"json"}, Pointers: inference.PointerOptional, MergeDocuments: true, OmitZero: true},
+			expected: `type Server struct {
+	Host string ` + "`json:\"host\"`" + `
+	Port *int ` + "`json:\"port,omitzero\"`" + `
 }
 `,
 		},
 		{
-			name: "multiple documents with single keys",
-			yamlInput: `
-config:
-  database: "localhost"
-  port: 5432
----
-user:
-  name: "admin"
-  role: "administrator"
-`,
-			tagPrefix: "json",
-			expected: `type Config struct {
-	Database *string ` + "`json:\"database\"`" + `
-	Port *int ` + "`json:\"port\"`" + `
+			name:   "samples with different single keys",
+			inputs: []string{"server:\n  host: a\n---\nclient:\n  host: b\n"},
+			opts:   Options{Tags: []string{"json"}, Pointers: inference.PointerOptional, MergeDocuments: true},
+			expected: `type Document struct {
+	Server *Server ` + "`json:\"server,omitempty\"`" + `
+	Client *Client ` + "`json:\"client,omitempty\"`" + `
 }
 
-type User struct {
-	Name *string ` + "`json:\"name\"`" + `
-	Role *string ` + "`json:\"role\"`" + `
+type Client struct {
+	Host string ` + "`json:\"host\"`" + `
+}
+
+type Server struct {
+	Host string ` + "`json:\"host\"`" + `
 }
 `,
 		},
 		{
-			name: "complex nested with arrays",
-			yamlInput: `
-users:
-  - name: "john"
-    age: 30
-  - name: "jane"
-    age: 25
-settings:
-  theme: "dark"
-  features: ["feature1", "feature2"]
+			name:   "required fields validated",
+			inputs: []string{"name: a\nnote: \"\"\n---\nname: b\nport: 80\n"},
+			opts:   Options{Tags: []string{"json"}, Pointers: inference.PointerOptional, MergeDocuments: true, ValidateRequired: true},
+			expected: `type Document struct {
+	Name *string ` + "`json:\"name\" validate:\"required\"`" + `
+	Note *string ` + "`json:\"note,omitempty\"`" + `
+	Port *int ` + "`json:\"port,omitempty\"`" + `
+}
 `,
-			tagPrefix: "json",
+		},
+		{
+			name:   "default options",
+			inputs: []string{"name: a\n---\nname: b\nport: 80\n"},
+			opts: func() Options {
+				opts := DefaultOptions()
+				opts.MergeDocuments = true
+				return opts
+			}(),
 			expected: `type Document struct {
-	Users []NestedStruct ` + "`json:\"users\"`" + `
-	Settings Settings ` + "`json:\"settings\"`" + `
+	Name string ` + "`json:\"name\"`" + `
+	Port *int ` + "`json:\"port,omitempty\"`" + `
 }
-
-type Settings struct {
-	Theme *string ` + "`json:\"theme\"`" + `
-	Features []string ` + "`json:\"features\"`" + `
+`,
+		},
+		{
+			name:   "empty values present in every sample",
+			inputs: []string{"name: \"\"\nport: 0\nnote: null\n---\nname: b\nport: 0\nnote: c\n"},
+			opts:   Options{Tags: []string{"json"}, Pointers: inference.PointerOptional, MergeDocuments: true},
+			expected: `type Document struct {
+	Name string ` + "`json:\"name\"`" + `
+	Port int ` + "`json:\"port\"`" + `
+	Note *string ` + "`json:\"note,omitempty\"`" + `
+}
+`,
+		},
+		{
+			name:   "empty values present in every sample validated",
+			inputs: []string{"name: \"\"\nenabled: false\nport: 0\n---\nname: b\nenabled: true\nport: 0\n"},
+			opts:   Options{Tags: []string{"json"}, Pointers: inference.PointerOptional, MergeDocuments: true, ValidateRequired: true},
+			expected: `type Document struct {
+	Name *string ` + "`json:\"name\" validate:\"required\"`" + `
+	Enabled *bool ` + "`json:\"enabled\" validate:\"required\"`" + `
+	Port *int ` + "`json:\"port\" validate:\"required\"`" + `
 }
 `,
 		},
@@ -199,29 +1819,307 @@ type Settings struct {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			file, err := parser.ParseBytes([]byte(tt.yamlInput), 0)
+			var files []*ast.File
+			for i, input := range tt.inputs {
+				file, err := parser.ParseBytes([]byte(input), 0)
+				if err != nil {
+					t.Fatalf("Failed to parse YAML: %v", err)
+				}
+				file.Name = fmt.Sprintf("sample%d.yaml", i+1)
+				files = append(files, file)
+			}
+
+			result, err := New(tt.opts).Generate(files...)
 			if err != nil {
-				t.Fatalf("Failed to parse YAML: %v", err)
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Generate() result mismatch:\nExpected:\n%s\n\nGot:\n%s", tt.expected, result)
 			}
+		})
+	}
+}
 
-			gen := New()
-			result := gen.Generate(file, tt.tagPrefix, false)
+func TestGenerator_Schema(t *testing.T) {
+	yamlInput := `
+name: app
+servers:
+  - host: a
+    tls: null
+  - host: b
+    tls:
+      cert: c.pem
+---
+name: 5
+port: 80
+`
 
-			// Normalize whitespace for comparison
-			normalizeWhitespace := func(s string) string {
-				return strings.TrimSpace(strings.ReplaceAll(s, "\t", "    "))
+	file, err := parser.ParseBytes([]byte(yamlInput), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+	file.Name = "app.yaml"
+
+	s, err := New(DefaultOptions()).Schema(file)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if strings.Join(s.Roots, ",") != "Document1,Document2" {
+		t.Errorf("Roots = %v, want [Document1 Document2]", s.Roots)
+	}
+	if s.Keys != nil {
+		t.Errorf("Keys = %v, want none", s.Keys)
+	}
+
+	// Each field as key: type, optional fields prefixed with ~
+	var objects []string
+	for _, o := range s.Objects {
+		var fields []string
+		for _, f := range o.Fields {
+			field := f.Key + ": " + f.Type.String()
+			if f.Optional {
+				field = "~" + field
 			}
+			fields = append(fields, field)
+		}
+		objects = append(objects, o.Name+"{"+strings.Join(fields, ", ")+"}")
+	}
+	expected := []string{
+		"Document1{name: string, servers: []Server}",
+		"Document2{name: integer, port: integer}",
+		"Server{host: string, ~tls: ?TLS}",
+		"TLS{cert: string}",
+	}
+	if strings.Join(objects, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Objects = %v, want %v", objects, expected)
+	}
 
-			expectedNorm := normalizeWhitespace(tt.expected)
-			resultNorm := normalizeWhitespace(result)
+	source := s.Objects[2].Fields[1].Source
+	if source.File != "app.yaml" || source.Path != "Document1.servers.server.tls" || source.Line != 5 {
+		t.Errorf("Source of Server.tls = %+v", source)
+	}
+}
 
-			if resultNorm != expectedNorm {
-				t.Errorf("Generate() result mismatch:\nExpected:\n%s\n\nGot:\n%s", tt.expected, result)
+func TestGenerator_Generate_JSONSchema(t *testing.T) {
+	inputs := []string{
+		`
+# Server is the HTTP endpoint.
+server:
+  port: 80 # Listening port
+  hosts: [a, b]
+  tls: null
+`,
+		`
+server:
+  port: 8080
+  debug: true
+`,
+	}
+
+	expected := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {
+    "server": {
+      "$ref": "#/$defs/Server"
+    }
+  },
+  "required": [
+    "server"
+  ],
+  "additionalProperties": false,
+  "$defs": {
+    "Server": {
+      "description": "Server is the HTTP endpoint.",
+      "type": "object",
+      "properties": {
+        "port": {
+          "description": "Listening port",
+          "type": "integer"
+        },
+        "hosts": {
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
+        "tls": {
+          "type": "null"
+        },
+        "debug": {
+          "type": "boolean"
+        }
+      },
+      "required": [
+        "port"
+      ],
+      "additionalProperties": false
+    }
+  }
+}
+`
+
+	var files []*ast.File
+	for _, input := range inputs {
+		file, err := parser.ParseBytes([]byte(input), parser.ParseComments)
+		if err != nil {
+			t.Fatalf("Failed to parse YAML: %v", err)
+		}
+		files = append(files, file)
+	}
+
+	opts := DefaultOptions()
+	opts.Format = FormatJSONSchema
+	result, err := New(opts).Generate(files...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != expected {
+		t.Errorf("Generate() result mismatch:\nExpected:\n%s\n\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerator_Generate_JSONSchemaErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "unknown format", opts: Options{Format: "proto"}},
+		{name: "package", opts: Options{Format: FormatJSONSchema, Package: "config"}},
+		{name: "split", opts: Options{Format: FormatJSONSchema, Split: SplitStruct}},
+		{name: "values", opts: Options{Format: FormatJSONSchema, Values: true}},
+		{name: "verify", opts: Options{Format: FormatJSONSchema, Verify: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte("name: test\n"), 0)
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			if result, err := New(tt.opts).Generate(file); err == nil {
+				t.Errorf("Expected an error but got none, result:\n%s", result)
 			}
 		})
 	}
 }
 
+func TestGenerator_Roots(t *testing.T) {
+	inputs := []string{
+		`
+server:
+  port: 80
+`,
+		`
+name: app
+port: 80
+---
+items: [1, 2]
+---
+plain text
+`,
+	}
+
+	var files []*ast.File
+	for _, input := range inputs {
+		file, err := parser.ParseBytes([]byte(input), 0)
+		if err != nil {
+			t.Fatalf("Failed to parse YAML: %v", err)
+		}
+		files = append(files, file)
+	}
+
+	// A document without a mapping has no root struct, the documents at the
+	// same position of the files share theirs
+	expected := [][]Root{
+		{{Name: "Document1"}},
+		{{Name: "Document1"}, {Name: "Items"}, {}},
+	}
+
+	got := New(Options{Tags: []string{"json"}}).Roots(files...)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Roots() = %+v, want %+v", got, expected)
+	}
+}
+
+func TestGenerator_Generate_Reuse(t *testing.T) {
+	first, err := parser.ParseBytes([]byte("server:\n  host: localhost\n"), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+	second, err := parser.ParseBytes([]byte("client:\n  timeout: 30\n"), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	gen := New(DefaultOptions())
+	if _, err := gen.Generate(first); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result, err := gen.Generate(second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `type Client struct {
+	Timeout *int ` + "`json:\"timeout\"`" + `
+}
+`
+	if result != expected {
+		t.Errorf("Generate() leaked types from a previous call:\nExpected:\n%s\n\nGot:\n%s", expected, result)
+	}
+}
+
+func TestGenerator_Generate_Concurrent(t *testing.T) {
+	inputs := []string{
+		"server:\n  host: localhost\n  settings:\n    port: 80\n",
+		"client:\n  timeout: 30\n  settings:\n    retries: 3\n",
+		"items:\n  - name: a\n  - name: b\n    size: 2\n",
+		"name: doc1\n---\ntitle: doc2\n",
+	}
+
+	gen := New(DefaultOptions())
+
+	files := make([]*ast.File, len(inputs))
+	expected := make([]string, len(inputs))
+	for i, input := range inputs {
+		file, err := parser.ParseBytes([]byte(input), 0)
+		if err != nil {
+			t.Fatalf("Failed to parse YAML: %v", err)
+		}
+		files[i] = file
+
+		expected[i], err = New(DefaultOptions()).Generate(file)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	results := make([]string, len(inputs)*10)
+	errs := make([]error, len(results))
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = gen.Generate(files[i%len(files)])
+		}()
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		if errs[i] != nil {
+			t.Fatalf("Unexpected error: %v", errs[i])
+		}
+		if result != expected[i%len(expected)] {
+			t.Errorf("Concurrent Generate() mismatch:\nExpected:\n%s\n\nGot:\n%s", expected[i%len(expected)], result)
+		}
+	}
+}
+
 func TestGenerator_determineDocumentName(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -323,7 +2221,7 @@ name: "doc3"
 				t.Fatalf("Failed to parse YAML: %v", err)
 			}
 
-			gen := New()
+			gen := New(Options{})
 
 			var doc *ast.DocumentNode
 			if len(file.Docs) > 0 {
@@ -346,13 +2244,13 @@ func TestGenerator_Generate_EdgeCases(t *testing.T) {
 	tests := []struct {
 		name      string
 		yamlInput string
-		tagPrefix string
+		tags      []string
 		expected  string
 	}{
 		{
 			name:      "completely empty YAML",
 			yamlInput: ``,
-			tagPrefix: "json",
+			tags:      []string{"json"},
 			expected:  "",
 		},
 		{
@@ -361,13 +2259,13 @@ func TestGenerator_Generate_EdgeCases(t *testing.T) {
 # This is a comment
 # Another comment
 `,
-			tagPrefix: "json",
-			expected:  "",
+			tags:     []string{"json"},
+			expected: "",
 		},
 		{
 			name:      "null document",
 			yamlInput: `null`,
-			tagPrefix: "json",
+			tags:      []string{"json"},
 			expected:  "",
 		},
 		{
@@ -376,27 +2274,72 @@ func TestGenerator_Generate_EdgeCases(t *testing.T) {
 - item1
 - item2
 `,
-			tagPrefix: "json",
-			expected:  "",
+			tags:     []string{"json"},
+			expected: "",
 		},
 		{
 			name:      "string at root level",
 			yamlInput: `"just a string"`,
-			tagPrefix: "json",
+			tags:      []string{"json"},
 			expected:  "",
 		},
 		{
 			name:      "number at root level",
 			yamlInput: `42`,
-			tagPrefix: "json",
+			tags:      []string{"json"},
 			expected:  "",
 		},
 		{
 			name:      "boolean at root level",
 			yamlInput: `true`,
-			tagPrefix: "json",
+			tags:      []string{"json"},
 			expected:  "",
 		},
+		{
+			name: "infinities and NaN",
+			yamlInput: `
+max: .inf
+min: -.inf
+ratio: .nan
+limits: [1.5, .inf]
+`,
+			tags: []string{"json"},
+			expected: `type Document struct {
+	Max *float64 ` + "`json:\"max\"`" + `
+	Min *float64 ` + "`json:\"min\"`" + `
+	Ratio *float64 ` + "`json:\"ratio\"`" + `
+	Limits []float64 ` + "`json:\"limits\"`" + `
+}
+`,
+		},
+		{
+			name: "empty keys",
+			yamlInput: `
+name: app
+"": {a: 1}
+list:
+  "": [{b: 1}]
+`,
+			tags: nil,
+			expected: `type Document struct {
+	Name *string
+	X X
+	List List
+}
+
+type Item struct {
+	B *int
+}
+
+type List struct {
+	X []Item
+}
+
+type X struct {
+	A *int
+}
+`,
+		},
 		{
 			name: "mixed document types",
 			yamlInput: `
@@ -408,7 +2351,7 @@ user:
 count: 42
 active: true
 `,
-			tagPrefix: "json",
+			tags: []string{"json"},
 			expected: `type User struct {
 	Name *string ` + "`json:\"name\"`" + `
 }
@@ -428,8 +2371,11 @@ type Document3 struct {
 				t.Fatalf("Failed to parse YAML: %v", err)
 			}
 
-			gen := New()
-			result := gen.Generate(file, tt.tagPrefix, false)
+			gen := New(Options{Tags: tt.tags})
+			result, err := gen.Generate(file)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
 
 			// Normalize whitespace for comparison
 			normalizeWhitespace := func(s string) string {
@@ -450,7 +2396,7 @@ func TestGenerator_Generate_StructOrdering(t *testing.T) {
 	tests := []struct {
 		name      string
 		yamlInput string
-		tagPrefix string
+		tags      []string
 		checkFunc func(t *testing.T, result string)
 	}{
 		{
@@ -461,7 +2407,7 @@ user:
     settings:
       theme: "dark"
 `,
-			tagPrefix: "json",
+			tags: []string{"json"},
 			checkFunc: func(t *testing.T, result string) {
 				lines := strings.Split(result, "\n")
 				if len(lines) < 1 {
@@ -484,7 +2430,7 @@ app:
     redis:
       url: "redis://localhost"
 `,
-			tagPrefix: "json",
+			tags: []string{"json"},
 			checkFunc: func(t *testing.T, result string) {
 				appIndex := strings.Index(result, "type App struct")
 				databaseIndex := strings.Index(result, "type Database struct")
@@ -514,7 +2460,7 @@ user:
 data:
   items: ["a", "b"]
 `,
-			tagPrefix: "json",
+			tags: []string{"json"},
 			checkFunc: func(t *testing.T, result string) {
 				configIndex := strings.Index(result, "type Config struct")
 				userIndex := strings.Index(result, "type User struct")
@@ -539,8 +2485,11 @@ data:
 				t.Fatalf("Failed to parse YAML: %v", err)
 			}
 
-			gen := New()
-			result := gen.Generate(file, tt.tagPrefix, false)
+			gen := New(Options{Tags: tt.tags})
+			result, err := gen.Generate(file)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
 
 			tt.checkFunc(t, result)
 		})
@@ -554,34 +2503,39 @@ age: 30
 `
 
 	tests := []struct {
-		name      string
-		tagPrefix string
-		expected  string
+		name     string
+		tags     []string
+		expected string
 	}{
 		{
-			name:      "json tag prefix",
-			tagPrefix: "json",
-			expected:  "`json:\"name\"`",
+			name:     "json tag prefix",
+			tags:     []string{"json"},
+			expected: "`json:\"name\"`",
 		},
 		{
-			name:      "yaml tag prefix",
-			tagPrefix: "yaml",
-			expected:  "`yaml:\"name\"`",
+			name:     "yaml tag prefix",
+			tags:     []string{"yaml"},
+			expected: "`yaml:\"name\"`",
 		},
 		{
-			name:      "xml tag prefix",
-			tagPrefix: "xml",
-			expected:  "`xml:\"name\"`",
+			name:     "xml tag prefix",
+			tags:     []string{"xml"},
+			expected: "`xml:\"name\"`",
 		},
 		{
-			name:      "custom tag prefix",
-			tagPrefix: "custom",
-			expected:  "`custom:\"name\"`",
+			name:     "custom tag prefix",
+			tags:     []string{"custom"},
+			expected: "`custom:\"name\"`",
 		},
 		{
-			name:      "empty tag prefix",
-			tagPrefix: "",
-			expected:  "",
+			name:     "multiple tags",
+			tags:     []string{"json", "yaml", "mapstructure"},
+			expected: "`json:\"name\" yaml:\"name\" mapstructure:\"name\"`",
+		},
+		{
+			name:     "empty tag prefix",
+			tags:     nil,
+			expected: "",
 		},
 	}
 
@@ -592,8 +2546,11 @@ age: 30
 				t.Fatalf("Failed to parse YAML: %v", err)
 			}
 
-			gen := New()
-			result := gen.Generate(file, tt.tagPrefix, false)
+			gen := New(Options{Tags: tt.tags})
+			result, err := gen.Generate(file)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
 
 			if tt.expected == "" {
 				// For empty tag prefix, tags should be empty
@@ -640,8 +2597,11 @@ normal: "hello"
 				t.Fatalf("Failed to parse YAML: %v", err)
 			}
 
-			gen := New()
-			result := gen.Generate(file, "json", tt.useOmitZero)
+			gen := New(Options{Tags: []string{"json"}, OmitZero: tt.useOmitZero})
+			result, err := gen.Generate(file)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
 
 			if tt.useOmitZero {
 				if !strings.Contains(result, "omitzero") {
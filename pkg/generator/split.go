@@ -0,0 +1,139 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/richerve/yaml2go/pkg/codegen"
+	"github.com/richerve/yaml2go/pkg/ident"
+)
+
+// Split selects how GenerateFiles divides the generated code into files.
+type Split string
+
+const (
+	// SplitNone writes every struct to a single file.
+	SplitNone Split = "none"
+	// SplitDocument writes one file per YAML document, holding its root
+	// struct and the structs only reachable from it.
+	SplitDocument Split = "document"
+	// SplitStruct writes one file per struct.
+	SplitStruct Split = "struct"
+)
+
+// String and Set let a Split be used as a command line flag value.
+func (s *Split) String() string {
+	return string(*s)
+}
+
+func (s *Split) Set(value string) error {
+	split, err := ParseSplit(value)
+	if err != nil {
+		return err
+	}
+
+	*s = split
+	return nil
+}
+
+func ParseSplit(s string) (Split, error) {
+	switch Split(s) {
+	case SplitNone, SplitDocument, SplitStruct:
+		return Split(s), nil
+	default:
+		return "", fmt.Errorf("unknown split %q, expected %q, %q or %q", s, SplitNone, SplitDocument, SplitStruct)
+	}
+}
+
+// File is a generated Go source file.
+type File struct {
+	// Name is the base name of the file, without directory
+	Name   string
+	Source []byte
+}
+
+// defaultFileName names the single file generated with SplitNone.
+const defaultFileName = "types"
+
+// valuesFileName names the file holding the values of split types.
+const valuesFileName = "values"
+
+// fileSuffix ends the names of the generated files. The last element before
+// .go is never a GOOS, a GOARCH or test, which would make the go tool treat
+// a file named after a struct such as ConfigLinux or UnitTest specially.
+const fileSuffix = "_gen.go"
+
+// FilePattern matches the names of the files generated by GenerateFiles,
+// along with other files ending the same way.
+const FilePattern = "*" + fileSuffix
+
+// fileGroup is the structs and values written to a file named after name.
+type fileGroup struct {
+	name    string
+	structs []codegen.StructDef
+	values  []codegen.ValueDef
+}
+
+// groupByRoot returns a group per root struct holding the structs reachable
+// from it, in output order. A struct reachable from several roots belongs
+// to the first one.
+func groupByRoot(structs []codegen.StructDef, roots []string) []fileGroup {
+	defs := make(map[string]codegen.StructDef, len(structs))
+	for _, s := range structs {
+		defs[s.Name] = s
+	}
+
+	owner := make(map[string]string)
+	var visit func(name, root string)
+	visit = func(name, root string) {
+		def, ok := defs[name]
+		if _, owned := owner[name]; !ok || owned {
+			return
+		}
+		owner[name] = root
+
+		for _, field := range def.Fields {
+			visit(heldObject(field.Schema), root)
+		}
+	}
+	for _, root := range roots {
+		visit(root, root)
+	}
+
+	groups := make([]fileGroup, 0, len(roots))
+	for _, root := range roots {
+		group := fileGroup{name: root}
+		for _, s := range structs {
+			if owner[s.Name] == root {
+				group.structs = append(group.structs, s)
+			}
+		}
+		groups = append(groups, group)
+	}
+
+	// Structs that are not reachable from any root, if any, go with the
+	// first document
+	for _, s := range structs {
+		if _, owned := owner[s.Name]; !owned && len(groups) > 0 {
+			groups[0].structs = append(groups[0].structs, s)
+		}
+	}
+
+	return slices.DeleteFunc(groups, func(g fileGroup) bool {
+		return len(g.structs) == 0
+	})
+}
+
+// fileName returns the snake_case file name for name, with a numeric suffix
+// when another struct already produced it, e.g. HTTPServer and HttpServer.
+func fileName(name string, used map[string]bool) string {
+	base := ident.Convert(name, ident.Snake)
+	result := base + fileSuffix
+	for i := 2; used[result]; i++ {
+		result = base + "_" + strconv.Itoa(i) + fileSuffix
+	}
+	used[result] = true
+
+	return result
+}
@@ -0,0 +1,35 @@
+package generator
+
+import "testing"
+
+func TestFileName(t *testing.T) {
+	used := make(map[string]bool)
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "ServerSettings", expected: "server_settings_gen.go"},
+		{name: "ConfigLinux", expected: "config_linux_gen.go"},
+		{name: "UnitTest", expected: "unit_test_gen.go"},
+		{name: "HTTPServer", expected: "http_server_gen.go"},
+		{name: "HttpServer", expected: "http_server_2_gen.go"},
+	}
+
+	for _, tt := range tests {
+		if result := fileName(tt.name, used); result != tt.expected {
+			t.Errorf("fileName(%q) = %v, want %v", tt.name, result, tt.expected)
+		}
+	}
+}
+
+func TestParseSplit(t *testing.T) {
+	for _, split := range []Split{SplitNone, SplitDocument, SplitStruct} {
+		if result, err := ParseSplit(string(split)); err != nil || result != split {
+			t.Errorf("ParseSplit(%q) = %v, %v, want %v", split, result, err, split)
+		}
+	}
+
+	if _, err := ParseSplit("file"); err == nil {
+		t.Errorf("Expected an error for an unknown split")
+	}
+}
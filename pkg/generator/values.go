@@ -0,0 +1,133 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/richerve/yaml2go/pkg/codegen"
+	"github.com/richerve/yaml2go/pkg/diag"
+	"github.com/richerve/yaml2go/pkg/ident"
+	"github.com/richerve/yaml2go/pkg/literal"
+)
+
+// valueSuffix tells the variable holding the data of a document from its
+// root struct, a variable can't share the name of a type.
+const valueSuffix = "Value"
+
+// values returns a variable for each document of files holding its data,
+// typed by its root struct, along with the values that don't fit the types.
+// Documents without a root struct, such as scalar documents, have none.
+func (g *Generator) values(files []*ast.File, structs []codegen.StructDef) ([]codegen.ValueDef, diag.List) {
+	docs, _ := g.documents(files)
+
+	defined := make(map[string]bool, len(structs))
+	for _, s := range structs {
+		defined[s.Name] = true
+	}
+	// Documents sharing their root struct are told apart by file
+	shared := make(map[string]int)
+	for _, doc := range docs {
+		shared[doc.root]++
+	}
+
+	var values []codegen.ValueDef
+	fileDiags := make([]diag.List, len(files))
+	for i, doc := range docs {
+		if !defined[doc.root] {
+			continue
+		}
+
+		node := doc.body
+		if node == ast.Node(doc.node) {
+			node = doc.node.Body
+		}
+
+		file := files[doc.file]
+		b := literal.New(structs)
+		value := b.Value(node, doc.root, []string{doc.root})
+		fileDiags[doc.file] = append(fileDiags[doc.file], b.Diagnostics()...)
+
+		name := doc.root + valueSuffix
+		if shared[doc.root] > 1 && len(files) > 1 && file.Name != "" {
+			name = ident.Exported(fileStem(file.Name)) + name
+		}
+		name = uniqueValueName(name, defined)
+		defined[name] = true
+
+		position := diag.New(node, []string{doc.root}, "")
+		values = append(values, codegen.ValueDef{
+			Name:     name,
+			Value:    value,
+			Imports:  b.Imports(),
+			Pointers: b.Pointers(),
+			Comment:  fmt.Sprintf("%s holds the data of %s.", name, describeDocument(file, docIndex(docs, i))),
+			Source: codegen.Source{
+				File:   file.Name,
+				Path:   position.Path,
+				Line:   position.Line,
+				Column: position.Column,
+			},
+		})
+	}
+
+	var diags diag.List
+	for fi, list := range fileDiags {
+		list.Sort()
+		for _, d := range list {
+			d.File = files[fi].Name
+			diags = append(diags, d)
+		}
+	}
+
+	return values, diags
+}
+
+// docIndex returns the position of docs[i] in its file.
+func docIndex(docs []document, i int) int {
+	index := 0
+	for _, doc := range docs[:i] {
+		if doc.file == docs[i].file {
+			index++
+		}
+	}
+
+	return index
+}
+
+// describeDocument names the document at index of file in the comment of
+// its value.
+func describeDocument(file *ast.File, index int) string {
+	name := file.Name
+	if name == "" {
+		name = "the YAML"
+	}
+	if len(file.Docs) == 1 {
+		return name
+	}
+
+	return fmt.Sprintf("document %d of %s", index+1, name)
+}
+
+// fileStem returns the base name of a file without its extension, e.g. prod
+// for configs/prod.yaml.
+func fileStem(name string) string {
+	base := filepath.Base(name)
+
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// uniqueValueName returns name, followed by the first number from 2 making
+// it unique when a struct or another value is already defined with it.
+func uniqueValueName(name string, defined map[string]bool) string {
+	if !defined[name] {
+		return name
+	}
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s%d", name, i)
+		if !defined[candidate] {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,269 @@
+// Package golang emits the Go structs of a schema: the type of each field,
+// with pointers as selected by the pointer policy, and the struct tags
+// decoding its key.
+package golang
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/richerve/yaml2go/pkg/codegen"
+	"github.com/richerve/yaml2go/pkg/diag"
+	"github.com/richerve/yaml2go/pkg/ident"
+	"github.com/richerve/yaml2go/pkg/inference"
+	"github.com/richerve/yaml2go/pkg/schema"
+)
+
+// Options configures the Go code of the fields.
+type Options struct {
+	// Tags are the struct tag keys written on every field, in order.
+	Tags []string
+	// Cases maps tag keys to the naming convention of their values, tags
+	// without one keep the YAML key as written.
+	Cases map[string]ident.Case
+	// OmitFlags maps tag keys to the flag leaving out their empty values,
+	// FlagOmitEmpty or FlagOmitZero, e.g. omitzero for json only.
+	OmitFlags map[string]string
+	// OmitZero uses omitzero instead of omitempty for empty values, on the
+	// tags without a flag in OmitFlags.
+	OmitZero bool
+	// Pointers selects which scalar fields are pointers, the empty policy
+	// is inference.PointerAlways.
+	Pointers inference.PointerPolicy
+	// OmitOptional lets every optional field be left out, and only those:
+	// their tags get an omitempty flag, or omitzero, and structs become
+	// pointers. The empty values of the other fields are written.
+	OmitOptional bool
+	// ValidateRequired adds a validate:"required" tag to the fields that
+	// are not optional. Their scalars are pointers, the tag checks that the
+	// key is present rather than that its value isn't 0 or "".
+	ValidateRequired bool
+}
+
+// The tag flags leaving out empty values. Not every decoder understands
+// omitzero: encoding/json does since Go 1.24, as does github.com/goccy/go-yaml,
+// gopkg.in/yaml.v3 doesn't.
+const (
+	FlagOmitEmpty = "omitempty"
+	FlagOmitZero  = "omitzero"
+)
+
+// ParseOmitFlag returns s when it is one of the flags leaving out empty
+// values.
+func ParseOmitFlag(s string) (string, error) {
+	switch s {
+	case FlagOmitEmpty, FlagOmitZero:
+		return s, nil
+	default:
+		return "", fmt.Errorf("unknown omit flag %q, expected %q or %q", s, FlagOmitEmpty, FlagOmitZero)
+	}
+}
+
+// ValidateTag is the struct tag key of the validations of
+// github.com/go-playground/validator, see Options.ValidateRequired.
+const ValidateTag = "validate"
+
+// YAMLTag is the struct tag key of the YAML decoders, such as
+// github.com/goccy/go-yaml.
+const YAMLTag = "yaml"
+
+// Structs returns a struct for each object of s, in the same order, along
+// with warnings for the keys of a struct producing the same tag value. The
+// keys that can't be written in a struct tag, such as a"b or the empty key,
+// are left out with a warning.
+func Structs(s schema.Schema, opts Options) ([]codegen.StructDef, diag.List) {
+	structs := make([]codegen.StructDef, 0, len(s.Objects))
+	var diags diag.List
+	for _, o := range s.Objects {
+		def, structDiags := structDef(o, opts)
+		structs = append(structs, def)
+		diags = append(diags, structDiags...)
+	}
+
+	return structs, diags
+}
+
+func structDef(o schema.Object, opts Options) (codegen.StructDef, diag.List) {
+	var diags diag.List
+
+	// Keys holding each tag value, indexed by tag key and then value
+	tagValues := make(map[string]map[string]string)
+	for _, prefix := range opts.Tags {
+		tagValues[prefix] = make(map[string]string)
+	}
+
+	fields := make([]codegen.FieldDef, 0, len(o.Fields))
+	for _, field := range o.Fields {
+		if prefix := untaggable(field.Key, opts); prefix != "" {
+			diags = append(diags, warning(field.Source, "key %q can't be written in a %s struct tag, the field is left out of struct %s", field.Key, prefix, o.Name))
+			continue
+		}
+
+		fieldSchema, pointer := fieldType(field, opts)
+		goType := Type(fieldSchema, pointer)
+		if tag := undecodable(field.Type); tag != "" && slices.Contains(opts.Tags, YAMLTag) {
+			diags = append(diags, warning(field.Source, "github.com/goccy/go-yaml can't decode the %s value of key %q into %s, the yaml tag of struct %s doesn't decode the YAML", tag, field.Key, goType, o.Name))
+		}
+
+		// Fields are left out when empty, or with OmitOptional only when
+		// optional, a required key is written whatever its value
+		omit := field.Empty
+		if opts.OmitOptional {
+			omit = field.Optional
+		}
+
+		tags := make([]codegen.FieldTag, 0, len(opts.Tags))
+		for _, prefix := range opts.Tags {
+			tagValue := ident.Convert(field.Key, opts.Cases[prefix])
+			if other, exists := tagValues[prefix][tagValue]; exists && other != field.Key {
+				// Keys such as foo_bar and fooBar written in the same case
+				// would be decoded into one field, the tag is kept as is
+				diags = append(diags, warning(field.Source, "key %q produces %s tag %q already used by key %q in struct %s", field.Key, prefix, tagValue, other, o.Name))
+			}
+			tagValues[prefix][tagValue] = field.Key
+
+			tag := codegen.FieldTag{Prefix: prefix, Value: tagValue}
+			if omit {
+				tag.Flags = []string{omitFlag(prefix, opts)}
+			}
+			tags = append(tags, tag)
+		}
+		if opts.ValidateRequired && !field.Optional {
+			tags = append(tags, codegen.FieldTag{Prefix: ValidateTag, Value: "required"})
+		}
+
+		fields = append(fields, codegen.FieldDef{
+			Name:    field.Key,
+			GoName:  field.Name,
+			Type:    goType,
+			Schema:  fieldSchema,
+			Pointer: pointer,
+			Tags:    tags,
+			Comment: field.Comment,
+			Source:  codegen.Source(field.Source),
+		})
+	}
+
+	return codegen.StructDef{
+		Name:    o.Name,
+		Fields:  fields,
+		Comment: o.Comment,
+		Source:  codegen.Source(o.Source),
+	}, diags
+}
+
+// untaggable returns the first tag key whose value for key can't be written
+// in a struct tag, or "" when every tag can hold it.
+func untaggable(key string, opts Options) string {
+	for _, prefix := range opts.Tags {
+		if !codegen.ValidTagValue(ident.Convert(key, opts.Cases[prefix])) {
+			return prefix
+		}
+	}
+
+	return ""
+}
+
+// undecodable returns the tag of the values of t, !!set or !!binary, that
+// github.com/goccy/go-yaml can't decode into their Go type, or "".
+func undecodable(t schema.Type) string {
+	switch t.Kind {
+	case schema.KindSet:
+		return "!!set"
+	case schema.KindBinary:
+		return "!!binary"
+	case schema.KindArray:
+		return undecodable(t.Element())
+	default:
+		return ""
+	}
+}
+
+// omitFlag returns the tag flag leaving out the empty values of a field in
+// the tag of prefix.
+func omitFlag(prefix string, opts Options) string {
+	if flag, ok := opts.OmitFlags[prefix]; ok {
+		return flag
+	}
+	if opts.OmitZero {
+		return FlagOmitZero
+	}
+
+	return FlagOmitEmpty
+}
+
+// warning returns a warning located at source.
+func warning(source schema.Source, format string, args ...any) diag.Diagnostic {
+	return diag.Diagnostic{
+		File:     source.File,
+		Line:     source.Line,
+		Column:   source.Column,
+		Path:     source.Path,
+		Message:  fmt.Sprintf(format, args...),
+		Severity: diag.Warning,
+	}
+}
+
+// fieldType returns the type of the values of field and whether its Go
+// type points to them. Scalars are pointers as selected by the pointer
+// policy or when validated as required, structs when null in some of the
+// YAML or, with OmitOptional, when the field is optional.
+func fieldType(field schema.Field, opts Options) (schema.Type, bool) {
+	t := field.Type
+	if t.Kind == schema.KindObject && opts.OmitOptional && field.Optional {
+		// A left out struct is nil like a null one
+		t.Nullable = true
+		return t, true
+	}
+
+	required := opts.ValidateRequired && !field.Optional
+	return t, Pointer(t, opts.Pointers.Pointer(field.Optional) || required)
+}
+
+// Pointer reports whether Type(t, pointer) is a pointer to the values of t:
+// scalars, and nullable structs, when pointer is set.
+func Pointer(t schema.Type, pointer bool) bool {
+	switch t.Kind {
+	case schema.KindString, schema.KindInteger, schema.KindFloat, schema.KindBoolean, schema.KindTimestamp:
+		return pointer
+	case schema.KindObject:
+		return pointer && t.Nullable
+	default:
+		return false
+	}
+}
+
+// Type returns the Go type of the values of t, a pointer to them as
+// reported by Pointer. Elements of slices are pointers when nullable, so
+// that their null elements are kept.
+func Type(t schema.Type, pointer bool) string {
+	if Pointer(t, pointer) {
+		return "*" + Type(t, false)
+	}
+
+	switch t.Kind {
+	case schema.KindString:
+		return "string"
+	case schema.KindInteger:
+		return "int"
+	case schema.KindFloat:
+		return "float64"
+	case schema.KindBoolean:
+		return "bool"
+	case schema.KindTimestamp:
+		return "time.Time"
+	case schema.KindBinary:
+		return "[]byte"
+	case schema.KindSet:
+		return "map[string]struct{}"
+	case schema.KindMap:
+		return "map[string]any"
+	case schema.KindArray:
+		element := t.Element()
+		return "[]" + Type(element, element.Nullable)
+	case schema.KindObject:
+		return t.Object
+	default:
+		return "any"
+	}
+}
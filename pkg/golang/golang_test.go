@@ -0,0 +1,204 @@
+package golang
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/richerve/yaml2go/pkg/ident"
+	"github.com/richerve/yaml2go/pkg/inference"
+	"github.com/richerve/yaml2go/pkg/schema"
+)
+
+func TestType(t *testing.T) {
+	tests := []struct {
+		typ      schema.Type
+		pointer  bool
+		expected string
+	}{
+		{typ: schema.Type{Kind: schema.KindString}, pointer: true, expected: "*string"},
+		{typ: schema.Type{Kind: schema.KindInteger}, expected: "int"},
+		{typ: schema.Type{Kind: schema.KindFloat, Nullable: true}, pointer: true, expected: "*float64"},
+		{typ: schema.Type{Kind: schema.KindTimestamp}, pointer: true, expected: "*time.Time"},
+		{typ: schema.Type{Kind: schema.KindBinary}, pointer: true, expected: "[]byte"},
+		{typ: schema.Type{Kind: schema.KindSet}, pointer: true, expected: "map[string]struct{}"},
+		{typ: schema.Type{Kind: schema.KindMap}, pointer: true, expected: "map[string]any"},
+		{typ: schema.Type{Kind: schema.KindNull}, pointer: true, expected: "any"},
+		{typ: schema.ArrayOf(schema.ArrayOf(schema.Type{Kind: schema.KindBoolean})), pointer: true, expected: "[][]bool"},
+		{typ: schema.ArrayOf(schema.Type{Kind: schema.KindInteger, Nullable: true}), expected: "[]*int"},
+		{typ: schema.ArrayOf(schema.Type{Kind: schema.KindObject, Object: "Server", Nullable: true}), expected: "[]*Server"},
+		{typ: schema.ObjectOf("Server"), pointer: true, expected: "Server"},
+		{typ: schema.Type{Kind: schema.KindObject, Object: "Server", Nullable: true}, pointer: true, expected: "*Server"},
+		{typ: schema.Type{Kind: schema.KindObject, Object: "Server", Nullable: true}, expected: "Server"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if result := Type(tt.typ, tt.pointer); result != tt.expected {
+				t.Errorf("Type(%v, %v) = %v, want %v", tt.typ, tt.pointer, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStructs(t *testing.T) {
+	s := schema.Schema{
+		Roots: []string{"Document"},
+		Objects: []schema.Object{{
+			Name:    "Document",
+			Comment: "Document is the app.",
+			Fields: []schema.Field{
+				{Key: "name", Type: schema.Type{Kind: schema.KindString}},
+				{Key: "max_port", Type: schema.Type{Kind: schema.KindInteger}, Optional: true, Empty: true},
+				{Key: "server", Type: schema.ObjectOf("Server"), Optional: true},
+				{Key: "max-port", Name: "MaxPort2", Type: schema.Type{Kind: schema.KindInteger}, Source: schema.Source{File: "app.yaml", Path: "Document.max-port", Line: 4, Column: 1}},
+			},
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		opts     Options
+		expected string
+		warnings []string
+	}{
+		{
+			name: "pointers and tag cases",
+			opts: Options{Tags: []string{"json", "env"}, Cases: map[string]ident.Case{"env": ident.ScreamingSnake}},
+			expected: "// Document is the app.\n" +
+				"type Document struct {\n" +
+				"\tName *string `json:\"name\" env:\"NAME\"`\n" +
+				"\tMaxPort *int `json:\"max_port,omitempty\" env:\"MAX_PORT,omitempty\"`\n" +
+				"\tServer Server `json:\"server\" env:\"SERVER\"`\n" +
+				"\tMaxPort2 *int `json:\"max-port\" env:\"MAX_PORT\"`\n" +
+				"}\n",
+			warnings: []string{`app.yaml:4:1: Document.max-port: warning: key "max-port" produces env tag "MAX_PORT" already used by key "max_port" in struct Document`},
+		},
+		{
+			name: "optional fields omitted and required ones validated",
+			opts: Options{Tags: []string{"json", "mapstructure"}, OmitFlags: map[string]string{"json": FlagOmitZero}, Pointers: inference.PointerOptional, OmitOptional: true, ValidateRequired: true},
+			expected: "// Document is the app.\n" +
+				"type Document struct {\n" +
+				"\tName *string `json:\"name\" mapstructure:\"name\" validate:\"required\"`\n" +
+				"\tMaxPort *int `json:\"max_port,omitzero\" mapstructure:\"max_port,omitempty\"`\n" +
+				"\tServer *Server `json:\"server,omitzero\" mapstructure:\"server,omitempty\"`\n" +
+				"\tMaxPort2 *int `json:\"max-port\" mapstructure:\"max-port\" validate:\"required\"`\n" +
+				"}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			structs, diags := Structs(s, tt.opts)
+			if len(structs) != 1 {
+				t.Fatalf("Expected 1 struct, got %d", len(structs))
+			}
+			if result := structs[0].String(); result != tt.expected {
+				t.Errorf("Structs() mismatch:\nExpected:\n%s\nGot:\n%s", tt.expected, result)
+			}
+
+			var warnings []string
+			for _, d := range diags {
+				warnings = append(warnings, d.Error())
+			}
+			if strings.Join(warnings, "\n") != strings.Join(tt.warnings, "\n") {
+				t.Errorf("Structs() warnings = %v, want %v", warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestStructs_UndecodableTypes(t *testing.T) {
+	source := func(key string, line int) schema.Source {
+		return schema.Source{File: "app.yaml", Path: "Document." + key, Line: line, Column: 1}
+	}
+	s := schema.Schema{
+		Roots: []string{"Document"},
+		Objects: []schema.Object{{
+			Name: "Document",
+			Fields: []schema.Field{
+				{Key: "name", Type: schema.Type{Kind: schema.KindString}, Source: source("name", 1)},
+				{Key: "hosts", Type: schema.Type{Kind: schema.KindSet}, Source: source("hosts", 2)},
+				{Key: "certs", Type: schema.ArrayOf(schema.Type{Kind: schema.KindBinary}), Source: source("certs", 3)},
+			},
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		tags     []string
+		expected []string
+	}{
+		{
+			name: "yaml tag",
+			tags: []string{"json", "yaml"},
+			expected: []string{
+				"app.yaml:2:1: Document.hosts: warning: github.com/goccy/go-yaml can't decode the !!set value of key \"hosts\" into map[string]struct{}, the yaml tag of struct Document doesn't decode the YAML",
+				"app.yaml:3:1: Document.certs: warning: github.com/goccy/go-yaml can't decode the !!binary value of key \"certs\" into [][]byte, the yaml tag of struct Document doesn't decode the YAML",
+			},
+		},
+		{
+			name:     "json tag",
+			tags:     []string{"json"},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, diags := Structs(s, Options{Tags: tt.tags})
+
+			var warnings []string
+			for _, d := range diags {
+				warnings = append(warnings, d.Error())
+			}
+			if strings.Join(warnings, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("Structs() warnings:\n%s\nwant:\n%s", strings.Join(warnings, "\n"), strings.Join(tt.expected, "\n"))
+			}
+		})
+	}
+}
+
+func TestStructs_UntaggableKeys(t *testing.T) {
+	source := func(key string, line int) schema.Source {
+		return schema.Source{File: "app.yaml", Path: "Document." + key, Line: line, Column: 1}
+	}
+	str := schema.Type{Kind: schema.KindString}
+	s := schema.Schema{
+		Roots: []string{"Document"},
+		Objects: []schema.Object{{
+			Name: "Document",
+			Fields: []schema.Field{
+				{Key: "name", Type: str},
+				{Key: `a"b`, Name: "AB", Type: str, Source: source(`a"b`, 2)},
+				{Key: "a`b", Name: "AB2", Type: str, Source: source("a`b", 3)},
+				{Key: "e,f", Name: "EF", Type: str, Source: source("e,f", 4)},
+				{Key: "", Name: "Field", Type: str, Source: source("", 5)},
+				{Key: "-", Name: "Dash", Type: str},
+			},
+		}},
+	}
+
+	structs, diags := Structs(s, Options{Tags: []string{"json"}, Pointers: inference.PointerNever})
+
+	expected := "type Document struct {\n" +
+		"\tName string `json:\"name\"`\n" +
+		"\tDash string `json:\"-,\"`\n" +
+		"}\n"
+	if result := structs[0].String(); result != expected {
+		t.Errorf("Structs() mismatch:\nExpected:\n%s\nGot:\n%s", expected, result)
+	}
+
+	var warnings []string
+	for _, d := range diags {
+		warnings = append(warnings, d.Error())
+	}
+	expectedWarnings := []string{
+		"app.yaml:2:1: Document.a\"b: warning: key \"a\\\"b\" can't be written in a json struct tag, the field is left out of struct Document",
+		"app.yaml:3:1: Document.a`b: warning: key \"a`b\" can't be written in a json struct tag, the field is left out of struct Document",
+		"app.yaml:4:1: Document.e,f: warning: key \"e,f\" can't be written in a json struct tag, the field is left out of struct Document",
+		"app.yaml:5:1: Document.: warning: key \"\" can't be written in a json struct tag, the field is left out of struct Document",
+	}
+	if strings.Join(warnings, "\n") != strings.Join(expectedWarnings, "\n") {
+		t.Errorf("Structs() warnings:\n%s\nwant:\n%s", strings.Join(warnings, "\n"), strings.Join(expectedWarnings, "\n"))
+	}
+}
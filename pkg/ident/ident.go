@@ -0,0 +1,199 @@
+package ident
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+	"unicode"
+)
+
+// initialisms are written in upper case as a whole, following the Go naming
+// conventions, e.g. UserID and APIURL instead of UserId and ApiUrl.
+var initialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true,
+	"DNS": true, "EOF": true, "GUID": true, "HTML": true, "HTTP": true,
+	"HTTPS": true, "ID": true, "IP": true, "JSON": true, "LHS": true,
+	"QPS": true, "RAM": true, "RHS": true, "RPC": true, "SLA": true,
+	"SMTP": true, "SQL": true, "SSH": true, "SSL": true, "TCP": true,
+	"TLS": true, "TTL": true, "UDP": true, "UI": true, "UID": true,
+	"UUID": true, "URI": true, "URL": true, "UTF8": true, "VM": true,
+	"XML": true, "XMPP": true, "XSRF": true, "XSS": true,
+}
+
+// prefix is prepended to names that can't start an exported identifier:
+// empty names, names starting with a digit and letters without case.
+const prefix = "X"
+
+// Exported returns the exported Go identifier for s. The words of s, split
+// on any character that is not a letter or a digit and on camelCase
+// boundaries, are capitalized and joined, with initialisms in upper case:
+// "api.url" becomes APIURL, "max connections" MaxConnections and "userId"
+// UserID.
+//
+// The result is always a valid exported identifier, names that would not be
+// get a leading X, e.g. X2fa. Exported identifiers never collide with the
+// Go keywords or the predeclared identifiers, which are all lower case.
+func Exported(s string) string {
+	// Keys written in upper case, such as MAX_CONNECTIONS, are normalized
+	// to MaxConnections. Single words are kept as written so that applying
+	// Exported to its own result returns it unchanged.
+	parts := words(s)
+	shouting := len(parts) > 1 && !strings.ContainsFunc(s, unicode.IsLower)
+
+	var name strings.Builder
+	for _, word := range parts {
+		name.WriteString(capitalize(word, shouting))
+	}
+
+	result := name.String()
+	if !IsExported(result) {
+		result = prefix + result
+	}
+
+	return result
+}
+
+// IsExported reports whether s can be used as an exported Go identifier.
+func IsExported(s string) bool {
+	return token.IsIdentifier(s) && token.IsExported(s)
+}
+
+// words splits s into words on every rune that is not a letter or a digit,
+// before an upper case letter following a lower case letter or a digit, and
+// before the last upper case letter of an upper case run followed by a lower
+// case letter, e.g. "HTTPServer" into HTTP and Server.
+func words(s string) []string {
+	var result []string
+	var current []rune
+
+	flush := func() {
+		if len(current) > 0 {
+			result = append(result, string(current))
+			current = nil
+		}
+	}
+
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+
+		if unicode.IsUpper(r) && len(current) > 0 {
+			prev := current[len(current)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+
+		current = append(current, r)
+	}
+	flush()
+
+	return result
+}
+
+// capitalize returns word with its first letter in upper case. Initialisms
+// are written in upper case, the other words keep only the first letter in
+// upper case when lower is set.
+func capitalize(word string, lower bool) string {
+	upper := strings.ToUpper(word)
+	if initialisms[upper] {
+		return upper
+	}
+
+	runes := []rune(word)
+	if lower {
+		runes = []rune(strings.ToLower(word))
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+
+	return string(runes)
+}
+
+// Case is a naming convention applied to a YAML key, used for the values of
+// struct tags.
+type Case string
+
+const (
+	// Keep leaves the key as written in the YAML.
+	Keep Case = "keep"
+	// Snake writes the key as snake_case.
+	Snake Case = "snake"
+	// Camel writes the key as camelCase.
+	Camel Case = "camel"
+	// Kebab writes the key as kebab-case.
+	Kebab Case = "kebab"
+	// Pascal writes the key as PascalCase.
+	Pascal Case = "pascal"
+	// ScreamingSnake writes the key as SCREAMING_SNAKE_CASE.
+	ScreamingSnake Case = "screaming_snake"
+)
+
+// String and Set let a Case be used as a command line flag value.
+func (c *Case) String() string {
+	return string(*c)
+}
+
+func (c *Case) Set(value string) error {
+	parsed, err := ParseCase(value)
+	if err != nil {
+		return err
+	}
+
+	*c = parsed
+	return nil
+}
+
+func ParseCase(s string) (Case, error) {
+	switch Case(s) {
+	case Keep, Snake, Camel, Kebab, Pascal, ScreamingSnake:
+		return Case(s), nil
+	default:
+		return "", fmt.Errorf("unknown case %q, expected one of %s, %s, %s, %s, %s or %s", s, Keep, Snake, Camel, Kebab, Pascal, ScreamingSnake)
+	}
+}
+
+// Convert returns s written in case c, using the same words as Exported but
+// without initialisms, e.g. "user_id" is userId in Camel. Keys without any
+// word, and the empty Case, keep s unchanged.
+func Convert(s string, c Case) string {
+	parts := words(s)
+	if len(parts) == 0 {
+		return s
+	}
+
+	lower := make([]string, len(parts))
+	for i, word := range parts {
+		lower[i] = strings.ToLower(word)
+	}
+
+	switch c {
+	case Snake:
+		return strings.Join(lower, "_")
+	case Kebab:
+		return strings.Join(lower, "-")
+	case ScreamingSnake:
+		return strings.ToUpper(strings.Join(lower, "_"))
+	case Camel:
+		return lower[0] + title(lower[1:])
+	case Pascal:
+		return title(lower)
+	default:
+		return s
+	}
+}
+
+// title joins words with the first letter of each in upper case.
+func title(words []string) string {
+	var sb strings.Builder
+	for _, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		sb.WriteString(string(runes))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,227 @@
+package ident
+
+import "testing"
+
+func TestExported(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "basic string",
+			input:    "hello",
+			expected: "Hello",
+		},
+		{
+			name:     "snake_case",
+			input:    "user_name",
+			expected: "UserName",
+		},
+		{
+			name:     "kebab-case",
+			input:    "user-name",
+			expected: "UserName",
+		},
+		{
+			name:     "mixed separators",
+			input:    "user_name-id",
+			expected: "UserNameID",
+		},
+		{
+			name:     "multiple underscores",
+			input:    "user__name",
+			expected: "UserName",
+		},
+		{
+			name:     "dots",
+			input:    "api.url",
+			expected: "APIURL",
+		},
+		{
+			name:     "spaces",
+			input:    "max connections",
+			expected: "MaxConnections",
+		},
+		{
+			name:     "other punctuation",
+			input:    "path/to:key",
+			expected: "PathToKey",
+		},
+		{
+			name:     "leading digit",
+			input:    "2fa",
+			expected: "X2fa",
+		},
+		{
+			name:     "initialism suffix",
+			input:    "user_id",
+			expected: "UserID",
+		},
+		{
+			name:     "initialism prefix",
+			input:    "ssl_enabled",
+			expected: "SSLEnabled",
+		},
+		{
+			name:     "initialisms only",
+			input:    "http_tls",
+			expected: "HTTPTLS",
+		},
+		{
+			name:     "camelCase input",
+			input:    "userName",
+			expected: "UserName",
+		},
+		{
+			name:     "camelCase with initialism",
+			input:    "userId",
+			expected: "UserID",
+		},
+		{
+			name:     "PascalCase input",
+			input:    "UserName",
+			expected: "UserName",
+		},
+		{
+			name:     "upper case run before word",
+			input:    "HTTPServer",
+			expected: "HTTPServer",
+		},
+		{
+			name:     "upper case words",
+			input:    "MAX_CONNECTIONS",
+			expected: "MaxConnections",
+		},
+		{
+			name:     "upper case word",
+			input:    "MAX",
+			expected: "MAX",
+		},
+		{
+			name:     "with numbers",
+			input:    "user_id_123",
+			expected: "UserID123",
+		},
+		{
+			name:     "version suffix",
+			input:    "api_key_config_v2",
+			expected: "APIKeyConfigV2",
+		},
+		{
+			name:     "unicode letters",
+			input:    "émail_adresse",
+			expected: "ÉmailAdresse",
+		},
+		{
+			name:     "letters without case",
+			input:    "名前",
+			expected: "X名前",
+		},
+		{
+			name:     "starts with separator",
+			input:    "_user_name",
+			expected: "UserName",
+		},
+		{
+			name:     "only separators",
+			input:    "___",
+			expected: "X",
+		},
+		{
+			name:     "empty string",
+			input:    "",
+			expected: "X",
+		},
+		{
+			name:     "keyword",
+			input:    "type",
+			expected: "Type",
+		},
+		{
+			name:     "predeclared identifier",
+			input:    "string",
+			expected: "String",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Exported(tt.input)
+			if result != tt.expected {
+				t.Errorf("Exported(%q) = %v, want %v", tt.input, result, tt.expected)
+			}
+			if !IsExported(result) {
+				t.Errorf("Exported(%q) = %v is not a valid exported identifier", tt.input, result)
+			}
+			if again := Exported(result); again != result {
+				t.Errorf("Exported(%q) = %v, want it unchanged", result, again)
+			}
+		})
+	}
+}
+
+func TestIsExported(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{input: "Name", expected: true},
+		{input: "X2fa", expected: true},
+		{input: "name", expected: false},
+		{input: "2fa", expected: false},
+		{input: "Api.url", expected: false},
+		{input: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := IsExported(tt.input)
+			if result != tt.expected {
+				t.Errorf("IsExported(%q) = %v, want %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		input    string
+		c        Case
+		expected string
+	}{
+		{input: "maxConnections", c: Keep, expected: "maxConnections"},
+		{input: "maxConnections", c: "", expected: "maxConnections"},
+		{input: "maxConnections", c: Snake, expected: "max_connections"},
+		{input: "max-connections", c: Camel, expected: "maxConnections"},
+		{input: "max_connections", c: Kebab, expected: "max-connections"},
+		{input: "max connections", c: Pascal, expected: "MaxConnections"},
+		{input: "maxConnections", c: ScreamingSnake, expected: "MAX_CONNECTIONS"},
+		{input: "MAX_CONNECTIONS", c: Camel, expected: "maxConnections"},
+		{input: "HTTPServer", c: Snake, expected: "http_server"},
+		{input: "user_id", c: Camel, expected: "userId"},
+		{input: "2fa_code", c: Pascal, expected: "2faCode"},
+		{input: "---", c: Snake, expected: "---"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.c)+"/"+tt.input, func(t *testing.T) {
+			result := Convert(tt.input, tt.c)
+			if result != tt.expected {
+				t.Errorf("Convert(%q, %q) = %v, want %v", tt.input, tt.c, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseCase(t *testing.T) {
+	for _, c := range []Case{Keep, Snake, Camel, Kebab, Pascal, ScreamingSnake} {
+		if result, err := ParseCase(string(c)); err != nil || result != c {
+			t.Errorf("ParseCase(%q) = %v, %v, want %v", c, result, err, c)
+		}
+	}
+
+	if _, err := ParseCase("title"); err == nil {
+		t.Errorf("Expected an error for an unknown case")
+	}
+}
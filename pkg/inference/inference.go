@@ -1,69 +1,192 @@
 package inference
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/goccy/go-yaml/ast"
 	"github.com/richerve/yaml2go/pkg/codegen"
+	"github.com/richerve/yaml2go/pkg/naming"
+	"github.com/richerve/yaml2go/pkg/schema"
+)
+
+// PointerPolicy selects which scalar fields are generated as pointers.
+type PointerPolicy string
+
+const (
+	// PointerAlways generates every string, number and boolean as a pointer.
+	PointerAlways PointerPolicy = "always"
+	// PointerNever generates every scalar as a plain value.
+	PointerNever PointerPolicy = "never"
+	// PointerOptional generates pointers only for optional values: empty or
+	// null in the YAML, or missing from some of the merged mappings.
+	PointerOptional PointerPolicy = "optional"
 )
 
-func DetermineType(node ast.Node, fieldName string, structs map[string]codegen.StructDef, path []string) string {
+// String and Set let a PointerPolicy be used as a command line flag value.
+func (p *PointerPolicy) String() string {
+	return string(*p)
+}
+
+func (p *PointerPolicy) Set(value string) error {
+	policy, err := ParsePointerPolicy(value)
+	if err != nil {
+		return err
+	}
+
+	*p = policy
+	return nil
+}
+
+func ParsePointerPolicy(s string) (PointerPolicy, error) {
+	switch PointerPolicy(s) {
+	case PointerAlways, PointerNever, PointerOptional:
+		return PointerPolicy(s), nil
+	default:
+		return "", fmt.Errorf("unknown pointer policy %q, expected %q, %q or %q", s, PointerAlways, PointerNever, PointerOptional)
+	}
+}
+
+// Pointer reports whether policy generates a pointer for a scalar field,
+// optional as defined by PointerOptional. The empty policy is PointerAlways.
+func (p PointerPolicy) Pointer(optional bool) bool {
+	switch p {
+	case PointerNever:
+		return false
+	case PointerOptional:
+		return optional
+	default:
+		return true
+	}
+}
+
+// DetermineType returns the type of the value of fieldName found in the
+// object at path. Object names are looked up in names, a nil registry uses
+// the capitalized field name.
+func DetermineType(node ast.Node, fieldName string, names *naming.Registry, path []string) schema.Type {
 	switch n := node.(type) {
-	case *ast.StringNode:
-		return "*string"
+	case *ast.StringNode, *ast.LiteralNode:
+		return schema.Type{Kind: schema.KindString}
 
 	case *ast.IntegerNode:
-		return "*int"
+		return schema.Type{Kind: schema.KindInteger}
 
-	case *ast.FloatNode:
-		return "*float64"
+	case *ast.FloatNode, *ast.InfinityNode, *ast.NanNode:
+		return schema.Type{Kind: schema.KindFloat}
 
 	case *ast.BoolNode:
-		return "*bool"
+		return schema.Type{Kind: schema.KindBoolean}
+
+	case *ast.TagNode:
+		if tagType, ok := TagType(n); ok {
+			return tagType
+		}
+		// Collection and unknown tags are typed by their value
+		return DetermineType(n.Value, fieldName, names, path)
 
 	case *ast.NullNode:
-		return "interface{}"
+		// Unknown until a concrete value is found for the same path
+		return schema.Type{Kind: schema.KindNull}
 
 	case *ast.SequenceNode:
-		if len(n.Values) == 0 {
-			return "[]any"
-		}
+		// Every element is considered and their types unified. Mappings
+		// inside the sequence are named after the singular form of the
+		// field, nested sequences keep the field name so the innermost
+		// elements get it. Null elements make the type of the others
+		// nullable.
+		var elementType *schema.Type
+		null := false
+		for _, value := range n.Values {
+			if _, ok := value.(*ast.NullNode); ok {
+				null = true
+				continue
+			}
+
+			elementName, elementPath := fieldName, path
+			if _, nested := value.(*ast.SequenceNode); !nested {
+				elementName = codegen.ElementName(fieldName)
+				elementPath = append(path[:len(path):len(path)], fieldName)
+			}
+			t := DetermineType(value, elementName, names, elementPath)
 
-		// Determine element type from first element
-		elementType := DetermineType(n.Values[0], "", structs, path)
-		// For arrays, use non-pointer versions of basic types
-		switch elementType {
-		case "*string":
-			elementType = "string"
-		case "*int":
-			elementType = "int"
-		case "*float64":
-			elementType = "float64"
-		case "*bool":
-			elementType = "bool"
+			if elementType == nil {
+				elementType = &t
+			} else {
+				unified := schema.Unify(*elementType, t)
+				elementType = &unified
+			}
+		}
+		if elementType == nil {
+			// No elements, or only null ones
+			return schema.ArrayOf(schema.Type{Kind: schema.KindAny})
 		}
-		return "[]" + elementType
+		if null {
+			return schema.ArrayOf(schema.Unify(*elementType, schema.Type{Kind: schema.KindNull}))
+		}
+		return schema.ArrayOf(*elementType)
 
 	case *ast.MappingNode:
 		if len(n.Values) == 0 {
-			return "map[string]any"
-		}
-
-		// Non-empty mapping becomes a struct
-		structName := codegen.Capitalize(fieldName)
-		if structName == "" {
-			structName = "NestedStruct"
+			return schema.Type{Kind: schema.KindMap}
 		}
 
-		return structName
+		// Non-empty mapping becomes an object, named as the visitor names
+		// its struct
+		return schema.ObjectOf(names.StructName(append(path[:len(path):len(path)], fieldName)))
 
 	default:
-		return "interface{}"
+		return schema.Type{Kind: schema.KindAny}
 	}
 }
 
+// tagTypes holds the type of the YAML core schema tags that force the type
+// of their value, !!map, !!seq and custom tags keep the type of the value.
+var tagTypes = map[string]schema.Type{
+	"!!str":       {Kind: schema.KindString},
+	"!!int":       {Kind: schema.KindInteger},
+	"!!float":     {Kind: schema.KindFloat},
+	"!!bool":      {Kind: schema.KindBoolean},
+	"!!null":      {Kind: schema.KindAny},
+	"!!timestamp": {Kind: schema.KindTimestamp},
+	"!!binary":    {Kind: schema.KindBinary},
+	"!!set":       {Kind: schema.KindSet},
+}
+
+// TagName returns the tag of node in its shorthand form, e.g. !!str for both
+// !!str and !<tag:yaml.org,2002:str>.
+func TagName(node *ast.TagNode) string {
+	tag := node.Start.Value
+	if long, ok := strings.CutPrefix(tag, "!<tag:yaml.org,2002:"); ok {
+		return "!!" + strings.TrimSuffix(long, ">")
+	}
+
+	return tag
+}
+
+// TagType returns the type forced by the tag of node, if any.
+func TagType(node *ast.TagNode) (schema.Type, bool) {
+	tagType, ok := tagTypes[TagName(node)]
+	return tagType, ok
+}
+
+// IsOptionalValue reports whether the value of a field may be absent, that
+// is when it is empty or null.
+func IsOptionalValue(node ast.Node) bool {
+	if _, ok := node.(*ast.NullNode); ok {
+		return true
+	}
+
+	return IsEmptyValue(node)
+}
+
 func IsEmptyValue(node ast.Node) bool {
 	switch n := node.(type) {
 	case *ast.StringNode:
 		return n.Value == ""
+	case *ast.LiteralNode:
+		return n.Value.Value == ""
+	case *ast.TagNode:
+		return IsEmptyValue(n.Value)
 	case *ast.IntegerNode:
 		// Check if the string representation is "0"
 		return n.String() == "0"
@@ -3,8 +3,9 @@ package inference
 import (
 	"testing"
 
+	"github.com/goccy/go-yaml/ast"
 	"github.com/goccy/go-yaml/parser"
-	"github.com/richerve/yaml2go/pkg/codegen"
+	"github.com/richerve/yaml2go/pkg/naming"
 )
 
 func TestDetermineType(t *testing.T) {
@@ -12,7 +13,6 @@ func TestDetermineType(t *testing.T) {
 		name      string
 		yamlInput string
 		fieldName string
-		structs   map[string]codegen.StructDef
 		path      []string
 		expected  string
 	}{
@@ -20,55 +20,153 @@ func TestDetermineType(t *testing.T) {
 			name:      "string node",
 			yamlInput: `"hello world"`,
 			fieldName: "message",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{},
-			expected:  "*string",
+			expected:  "string",
 		},
 		{
 			name:      "integer node",
 			yamlInput: `42`,
 			fieldName: "count",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{},
-			expected:  "*int",
+			expected:  "integer",
 		},
 		{
 			name:      "float node",
 			yamlInput: `3.14`,
 			fieldName: "pi",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{},
-			expected:  "*float64",
+			expected:  "float",
+		},
+		{
+			name:      "infinity node",
+			yamlInput: `-.inf`,
+			fieldName: "min",
+			path:      []string{},
+			expected:  "float",
+		},
+		{
+			name:      "nan node",
+			yamlInput: `.nan`,
+			fieldName: "ratio",
+			path:      []string{},
+			expected:  "float",
 		},
 		{
 			name:      "boolean node true",
 			yamlInput: `true`,
 			fieldName: "enabled",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{},
-			expected:  "*bool",
+			expected:  "boolean",
 		},
 		{
 			name:      "boolean node false",
 			yamlInput: `false`,
 			fieldName: "disabled",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{},
-			expected:  "*bool",
+			expected:  "boolean",
 		},
 		{
 			name:      "null node",
 			yamlInput: `null`,
 			fieldName: "nullable",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{},
-			expected:  "interface{}",
+			expected:  "null",
+		},
+		{
+			name:      "literal block scalar",
+			yamlInput: "|\n  multi\n  line\n",
+			fieldName: "text",
+			path:      []string{},
+			expected:  "string",
+		},
+		{
+			name:      "folded block scalar",
+			yamlInput: ">\n  folded\n",
+			fieldName: "text",
+			path:      []string{},
+			expected:  "string",
+		},
+		{
+			name:      "str tag",
+			yamlInput: `!!str 8080`,
+			fieldName: "port",
+			path:      []string{},
+			expected:  "string",
+		},
+		{
+			name:      "int tag",
+			yamlInput: `!!int "3"`,
+			fieldName: "count",
+			path:      []string{},
+			expected:  "integer",
+		},
+		{
+			name:      "float tag",
+			yamlInput: `!!float 1`,
+			fieldName: "ratio",
+			path:      []string{},
+			expected:  "float",
+		},
+		{
+			name:      "bool tag",
+			yamlInput: `!!bool "true"`,
+			fieldName: "enabled",
+			path:      []string{},
+			expected:  "boolean",
+		},
+		{
+			name:      "timestamp tag",
+			yamlInput: `!!timestamp 2024-01-01`,
+			fieldName: "created",
+			path:      []string{},
+			expected:  "timestamp",
+		},
+		{
+			name:      "binary tag",
+			yamlInput: `!!binary aGVsbG8=`,
+			fieldName: "blob",
+			path:      []string{},
+			expected:  "binary",
+		},
+		{
+			name:      "map tag",
+			yamlInput: `!!map {a: 1}`,
+			fieldName: "nested",
+			path:      []string{},
+			expected:  "Nested",
+		},
+		{
+			name:      "seq tag",
+			yamlInput: `!!seq [1, 2]`,
+			fieldName: "list",
+			path:      []string{},
+			expected:  "[]integer",
+		},
+		{
+			name:      "set tag",
+			yamlInput: `!!set {a, b}`,
+			fieldName: "members",
+			path:      []string{},
+			expected:  "set",
+		},
+		{
+			name:      "verbatim tag",
+			yamlInput: `!<tag:yaml.org,2002:str> 7`,
+			fieldName: "code",
+			path:      []string{},
+			expected:  "string",
+		},
+		{
+			name:      "tagged sequence elements",
+			yamlInput: `[!!str 1, !!str 2]`,
+			fieldName: "codes",
+			path:      []string{},
+			expected:  "[]string",
 		},
 		{
 			name:      "empty sequence",
 			yamlInput: `[]`,
 			fieldName: "items",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{},
 			expected:  "[]any",
 		},
@@ -76,7 +174,6 @@ func TestDetermineType(t *testing.T) {
 			name:      "string sequence",
 			yamlInput: `["item1", "item2"]`,
 			fieldName: "items",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{},
 			expected:  "[]string",
 		},
@@ -84,39 +181,34 @@ func TestDetermineType(t *testing.T) {
 			name:      "integer sequence",
 			yamlInput: `[1, 2, 3]`,
 			fieldName: "numbers",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{},
-			expected:  "[]int",
+			expected:  "[]integer",
 		},
 		{
 			name:      "float sequence",
 			yamlInput: `[1.1, 2.2, 3.3]`,
 			fieldName: "floats",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{},
-			expected:  "[]float64",
+			expected:  "[]float",
 		},
 		{
 			name:      "boolean sequence",
 			yamlInput: `[true, false]`,
 			fieldName: "flags",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{},
-			expected:  "[]bool",
+			expected:  "[]boolean",
 		},
 		{
 			name:      "empty mapping",
 			yamlInput: `{}`,
 			fieldName: "config",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{},
-			expected:  "map[string]any",
+			expected:  "map",
 		},
 		{
 			name:      "non-empty mapping",
 			yamlInput: `{name: "test"}`,
 			fieldName: "user",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{},
 			expected:  "User",
 		},
@@ -124,31 +216,76 @@ func TestDetermineType(t *testing.T) {
 			name:      "mapping with empty field name",
 			yamlInput: `{key: "value"}`,
 			fieldName: "",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{},
-			expected:  "NestedStruct",
+			expected:  "X",
 		},
 		{
 			name:      "nested sequence of mappings",
 			yamlInput: `[{name: "user1"}, {name: "user2"}]`,
 			fieldName: "users",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{},
-			expected:  "[]NestedStruct",
+			expected:  "[]User",
 		},
 		{
-			name:      "sequence with mixed types (first is string)",
+			name:      "nested sequence of sequences of mappings",
+			yamlInput: `[[{name: "user1"}]]`,
+			fieldName: "groups",
+			path:      []string{},
+			expected:  "[][]Group",
+		},
+		{
+			name:      "sequence of mappings with singular field name",
+			yamlInput: `[{name: "user1"}]`,
+			fieldName: "data",
+			path:      []string{},
+			expected:  "[]DataItem",
+		},
+		{
+			name:      "sequence with mixed types",
 			yamlInput: `["string", 123]`,
 			fieldName: "mixed",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{},
-			expected:  "[]string",
+			expected:  "[]any",
+		},
+		{
+			name:      "sequence of integers and floats",
+			yamlInput: `[1, 2.5]`,
+			fieldName: "values",
+			path:      []string{},
+			expected:  "[]float",
+		},
+		{
+			name:      "sequence with null elements",
+			yamlInput: `[1, null, 2]`,
+			fieldName: "values",
+			path:      []string{},
+			expected:  "[]?integer",
+		},
+		{
+			name:      "sequence of only null elements",
+			yamlInput: `[null, null]`,
+			fieldName: "values",
+			path:      []string{},
+			expected:  "[]any",
+		},
+		{
+			name:      "nested sequences unified",
+			yamlInput: `[[1], [], [2.5]]`,
+			fieldName: "matrix",
+			path:      []string{},
+			expected:  "[][]float",
+		},
+		{
+			name:      "mappings and scalars",
+			yamlInput: `[{a: 1}, "b"]`,
+			fieldName: "items",
+			path:      []string{},
+			expected:  "[]any",
 		},
 		{
 			name:      "complex nested structure",
 			yamlInput: `{user: {name: "john", age: 30}}`,
 			fieldName: "data",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{},
 			expected:  "Data",
 		},
@@ -156,7 +293,6 @@ func TestDetermineType(t *testing.T) {
 			name:      "snake_case field name",
 			yamlInput: `{key: "value"}`,
 			fieldName: "user_data",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{},
 			expected:  "UserData",
 		},
@@ -164,7 +300,6 @@ func TestDetermineType(t *testing.T) {
 			name:      "kebab-case field name",
 			yamlInput: `{key: "value"}`,
 			fieldName: "user-data",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{},
 			expected:  "UserData",
 		},
@@ -183,9 +318,9 @@ func TestDetermineType(t *testing.T) {
 			}
 
 			node := file.Docs[0].Body
-			result := DetermineType(node, tt.fieldName, tt.structs, tt.path)
+			result := DetermineType(node, tt.fieldName, nil, tt.path)
 
-			if result != tt.expected {
+			if result.String() != tt.expected {
 				t.Errorf("DetermineType() = %v, want %v", result, tt.expected)
 			}
 		})
@@ -194,15 +329,14 @@ func TestDetermineType(t *testing.T) {
 
 func TestDetermineType_UnknownNode(t *testing.T) {
 	// Test with a mock node type that's not handled
-	structs := make(map[string]codegen.StructDef)
 	path := []string{}
 
-	// For this test, we'll just verify that unknown nodes return interface{}
+	// For this test, we'll just verify that unknown nodes return any
 	// In practice, most nodes will be one of the handled types
-	result := DetermineType(nil, "unknown", structs, path)
-	expected := "interface{}"
+	result := DetermineType(nil, "unknown", nil, path)
+	expected := "any"
 
-	if result != expected {
+	if result.String() != expected {
 		t.Errorf("DetermineType() with unknown node = %v, want %v", result, expected)
 	}
 }
@@ -312,69 +446,121 @@ func TestIsEmptyValue_UnknownNode(t *testing.T) {
 	}
 }
 
-func TestDetermineType_WithExistingStructs(t *testing.T) {
+func TestDetermineType_WithRegistry(t *testing.T) {
+	yamlInput := `
+server:
+  settings:
+    port: 8080
+  workers:
+    - settings:
+        port: 9090
+products:
+  settings:
+    charset: utf8
+`
+
 	tests := []struct {
 		name      string
-		yamlInput string
-		fieldName string
-		structs   map[string]codegen.StructDef
+		strategy  naming.Strategy
 		path      []string
+		fieldName string
 		expected  string
 	}{
 		{
-			name:      "mapping with existing struct definitions",
-			yamlInput: `{name: "john", age: 30}`,
-			fieldName: "user",
-			structs: map[string]codegen.StructDef{
-				"User": {
-					Name: "User",
-					Fields: []codegen.FieldDef{
-						{Name: "name", Type: "string"},
-						{Name: "age", Type: "int"},
-					},
-				},
-			},
-			path:     []string{},
-			expected: "User",
-		},
-		{
-			name:      "sequence of mappings with complex path",
-			yamlInput: `[{id: 1}, {id: 2}]`,
-			fieldName: "items",
-			structs:   make(map[string]codegen.StructDef),
-			path:      []string{"root", "nested"},
-			expected:  "[]NestedStruct",
+			name:      "colliding mapping qualified by parent",
+			strategy:  naming.Parent,
+			path:      []string{"Document", "server"},
+			fieldName: "settings",
+			expected:  "ServerSettings",
+		},
+		{
+			name:      "other colliding mapping qualified by parent",
+			strategy:  naming.Parent,
+			path:      []string{"Document", "products"},
+			fieldName: "settings",
+			expected:  "ProductsSettings",
+		},
+		{
+			name:      "sequence element qualified by parent",
+			strategy:  naming.Parent,
+			path:      []string{"Document", "server", "workers", "worker"},
+			fieldName: "settings",
+			expected:  "WorkerSettings",
+		},
+		{
+			name:      "identical shapes share a name when merging",
+			strategy:  naming.Merge,
+			path:      []string{"Document", "server", "workers", "worker"},
+			fieldName: "settings",
+			expected:  "ServerSettings",
+		},
+		{
+			name:      "sequence of mappings",
+			strategy:  naming.Parent,
+			path:      []string{"Document", "server"},
+			fieldName: "workers",
+			expected:  "[]Worker",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Parse YAML input to get AST node
-			file, err := parser.ParseBytes([]byte(tt.yamlInput), 0)
+			file, err := parser.ParseBytes([]byte(yamlInput), 0)
 			if err != nil {
 				t.Fatalf("Failed to parse YAML: %v", err)
 			}
 
-			if len(file.Docs) == 0 {
-				t.Fatalf("No documents found in parsed YAML")
-			}
+			names := naming.NewRegistry(tt.strategy)
+			names.Collect(file.Docs[0], []string{"Document"})
+			names.Resolve()
 
-			node := file.Docs[0].Body
-			result := DetermineType(node, tt.fieldName, tt.structs, tt.path)
+			node := lookup(t, file.Docs[0].Body, append(tt.path[1:], tt.fieldName))
+			result := DetermineType(node, tt.fieldName, names, tt.path)
 
-			if result != tt.expected {
+			if result.String() != tt.expected {
 				t.Errorf("DetermineType() = %v, want %v", result, tt.expected)
 			}
 		})
 	}
 }
 
+// lookup follows keys through nested mappings, stepping into the first
+// element of sequences whose key has been singularized.
+func lookup(t *testing.T, node ast.Node, keys []string) ast.Node {
+	t.Helper()
+
+	for _, key := range keys {
+		if sequenceNode, ok := node.(*ast.SequenceNode); ok {
+			node = sequenceNode.Values[0]
+			continue
+		}
+
+		mappingNode, ok := node.(*ast.MappingNode)
+		if !ok {
+			t.Fatalf("Expected MappingNode for key %s, got %T", key, node)
+		}
+
+		found := false
+		for _, mappingValue := range mappingNode.Values {
+			if mappingValue.Key.String() == key {
+				node = mappingValue.Value
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Fatalf("Key %s not found", key)
+		}
+	}
+
+	return node
+}
+
 func TestDetermineType_EdgeCases(t *testing.T) {
 	tests := []struct {
 		name      string
 		yamlInput string
 		fieldName string
-		structs   map[string]codegen.StructDef
 		path      []string
 		expected  string
 	}{
@@ -382,31 +568,27 @@ func TestDetermineType_EdgeCases(t *testing.T) {
 			name:      "very long path",
 			yamlInput: `{key: "value"}`,
 			fieldName: "deep",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{"level1", "level2", "level3", "level4", "level5"},
 			expected:  "Deep",
 		},
 		{
-			name:      "nil structs map",
+			name:      "nil registry",
 			yamlInput: `"test"`,
 			fieldName: "field",
-			structs:   nil,
 			path:      []string{},
-			expected:  "*string",
+			expected:  "string",
 		},
 		{
 			name:      "sequence with null element",
 			yamlInput: `[null]`,
 			fieldName: "nulls",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{},
-			expected:  "[]interface{}",
+			expected:  "[]any",
 		},
 		{
 			name:      "deeply nested sequence",
 			yamlInput: `[[["nested"]]]`,
 			fieldName: "nested",
-			structs:   make(map[string]codegen.StructDef),
 			path:      []string{},
 			expected:  "[][][]string",
 		},
@@ -425,11 +607,94 @@ func TestDetermineType_EdgeCases(t *testing.T) {
 			}
 
 			node := file.Docs[0].Body
-			result := DetermineType(node, tt.fieldName, tt.structs, tt.path)
+			result := DetermineType(node, tt.fieldName, nil, tt.path)
 
-			if result != tt.expected {
+			if result.String() != tt.expected {
 				t.Errorf("DetermineType() = %v, want %v", result, tt.expected)
 			}
 		})
 	}
 }
+
+func TestParsePointerPolicy(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    PointerPolicy
+		expectError bool
+	}{
+		{input: "always", expected: PointerAlways},
+		{input: "never", expected: PointerNever},
+		{input: "optional", expected: PointerOptional},
+		{input: "sometimes", expectError: true},
+		{input: "", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParsePointerPolicy(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error for %q, got none", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("ParsePointerPolicy(%q) = %v, want %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPointerPolicy_Pointer(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   PointerPolicy
+		optional bool
+		expected bool
+	}{
+		{name: "always", policy: PointerAlways, expected: true},
+		{name: "empty policy is always", policy: "", expected: true},
+		{name: "never", policy: PointerNever, optional: true, expected: false},
+		{name: "optional on required field", policy: PointerOptional, expected: false},
+		{name: "optional on optional field", policy: PointerOptional, optional: true, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.policy.Pointer(tt.optional); result != tt.expected {
+				t.Errorf("Pointer(%v) = %v, want %v", tt.optional, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsOptionalValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		yamlInput string
+		expected  bool
+	}{
+		{name: "null", yamlInput: `null`, expected: true},
+		{name: "empty string", yamlInput: `""`, expected: true},
+		{name: "zero integer", yamlInput: `0`, expected: true},
+		{name: "non-empty string", yamlInput: `"hello"`, expected: false},
+		{name: "boolean false", yamlInput: `false`, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte(tt.yamlInput), 0)
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			result := IsOptionalValue(file.Docs[0].Body)
+			if result != tt.expected {
+				t.Errorf("IsOptionalValue() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
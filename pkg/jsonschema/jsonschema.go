@@ -0,0 +1,182 @@
+// Package jsonschema emits a JSON Schema, draft 2020-12, validating the YAML
+// documents a schema was inferred from: each object is a definition in
+// $defs, named like its Go struct, whose keys are the properties.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/richerve/yaml2go/pkg/schema"
+)
+
+// Draft identifies the JSON Schema dialect of the generated schemas.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Generate returns the JSON Schema of the documents described by s, as
+// indented JSON. The keys of an object that are not optional are required
+// and no other keys are allowed. A schema with several roots accepts a
+// document of any of them.
+func Generate(s schema.Schema) ([]byte, error) {
+	doc := keywords{{"$schema", Draft}}
+
+	var roots []keywords
+	for _, root := range s.Roots {
+		roots = append(roots, rootSchema(root, s.Keys[root]))
+	}
+	if len(roots) == 1 {
+		doc = append(doc, roots[0]...)
+	} else if len(roots) > 1 {
+		doc = append(doc, keyword{"anyOf", roots})
+	}
+
+	if len(s.Objects) > 0 {
+		defs := make(keywords, 0, len(s.Objects))
+		for _, o := range s.Objects {
+			defs = append(defs, keyword{o.Name, objectSchema(o)})
+		}
+		doc = append(doc, keyword{"$defs", defs})
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("encoding JSON Schema: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// rootSchema returns the schema of the documents of root, a mapping with key
+// holding the object when key is set.
+func rootSchema(root, key string) keywords {
+	if key == "" {
+		return ref(root)
+	}
+
+	return keywords{
+		{"type", "object"},
+		{"properties", keywords{{key, ref(root)}}},
+		{"required", []string{key}},
+		{"additionalProperties", false},
+	}
+}
+
+func objectSchema(o schema.Object) keywords {
+	var result keywords
+	if o.Comment != "" {
+		result = append(result, keyword{"description", o.Comment})
+	}
+	result = append(result, keyword{"type", "object"})
+
+	properties := make(keywords, 0, len(o.Fields))
+	required := []string{}
+	for _, field := range o.Fields {
+		property := typeSchema(field.Type)
+		if field.Comment != "" {
+			property = append(keywords{{"description", field.Comment}}, property...)
+		}
+		properties = append(properties, keyword{field.Key, property})
+
+		if !field.Optional {
+			required = append(required, field.Key)
+		}
+	}
+	result = append(result, keyword{"properties", properties})
+	if len(required) > 0 {
+		result = append(result, keyword{"required", required})
+	}
+
+	return append(result, keyword{"additionalProperties", false})
+}
+
+// typeSchema returns the schema of the values of t. Timestamps are strings,
+// as JSON has none, and values of any type are unconstrained.
+func typeSchema(t schema.Type) keywords {
+	var result keywords
+	switch t.Kind {
+	case schema.KindString, schema.KindTimestamp:
+		result = keywords{{"type", "string"}}
+	case schema.KindInteger:
+		result = keywords{{"type", "integer"}}
+	case schema.KindFloat:
+		result = keywords{{"type", "number"}}
+	case schema.KindBoolean:
+		result = keywords{{"type", "boolean"}}
+	case schema.KindNull:
+		result = keywords{{"type", "null"}}
+	case schema.KindBinary:
+		result = keywords{{"type", "string"}, {"contentEncoding", "base64"}}
+	case schema.KindSet:
+		// The elements of a set are keys with null values
+		result = keywords{{"type", "object"}, {"additionalProperties", keywords{{"type", "null"}}}}
+	case schema.KindMap:
+		result = keywords{{"type", "object"}}
+	case schema.KindArray:
+		result = keywords{{"type", "array"}}
+		if items := t.Element(); items.Kind != schema.KindAny {
+			result = append(result, keyword{"items", typeSchema(items)})
+		}
+	case schema.KindObject:
+		result = ref(t.Object)
+	default:
+		return keywords{}
+	}
+
+	if t.Nullable {
+		return nullable(result)
+	}
+
+	return result
+}
+
+// nullable returns schema s also accepting null.
+func nullable(s keywords) keywords {
+	if len(s) > 0 && s[0].name == "type" {
+		if name, ok := s[0].value.(string); ok {
+			return append(keywords{{"type", []string{name, "null"}}}, s[1:]...)
+		}
+	}
+
+	return keywords{{"anyOf", []keywords{s, {{"type", "null"}}}}}
+}
+
+func ref(object string) keywords {
+	return keywords{{"$ref", "#/$defs/" + object}}
+}
+
+// keyword is a member of a JSON object.
+type keyword struct {
+	name  string
+	value any
+}
+
+// keywords is a JSON object whose members are encoded in order, keeping
+// the keys of the YAML in the order they were written.
+type keywords []keyword
+
+func (k keywords) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	buf.WriteByte('{')
+	for i, member := range k {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := enc.Encode(member.name); err != nil {
+			return nil, err
+		}
+		buf.WriteByte(':')
+		if err := enc.Encode(member.value); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
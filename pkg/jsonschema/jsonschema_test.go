@@ -0,0 +1,185 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/richerve/yaml2go/pkg/schema"
+)
+
+func TestGenerate(t *testing.T) {
+	s := schema.Schema{
+		Roots: []string{"Server"},
+		Objects: []schema.Object{
+			{
+				Name:    "Server",
+				Comment: "Server is the <public> endpoint.",
+				Fields: []schema.Field{
+					{Key: "port", Type: schema.Type{Kind: schema.KindInteger}, Comment: "Listening port"},
+					{Key: "ratio", Type: schema.Type{Kind: schema.KindFloat, Nullable: true}, Optional: true},
+					{Key: "tags", Type: schema.ArrayOf(schema.Type{Kind: schema.KindString})},
+					{Key: "items", Type: schema.Type{Kind: schema.KindArray}, Optional: true},
+					{Key: "tls", Type: schema.Type{Kind: schema.KindObject, Object: "ServerTls", Nullable: true}, Optional: true},
+					{Key: "extra", Type: schema.Type{Kind: schema.KindAny}},
+				},
+			},
+			{
+				Name: "ServerTls",
+				Fields: []schema.Field{
+					{Key: "cert", Type: schema.Type{Kind: schema.KindBinary}},
+					{Key: "hosts", Type: schema.Type{Kind: schema.KindSet}},
+				},
+			},
+		},
+		Keys: map[string]string{"Server": "server"},
+	}
+
+	expected := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {
+    "server": {
+      "$ref": "#/$defs/Server"
+    }
+  },
+  "required": [
+    "server"
+  ],
+  "additionalProperties": false,
+  "$defs": {
+    "Server": {
+      "description": "Server is the <public> endpoint.",
+      "type": "object",
+      "properties": {
+        "port": {
+          "description": "Listening port",
+          "type": "integer"
+        },
+        "ratio": {
+          "type": [
+            "number",
+            "null"
+          ]
+        },
+        "tags": {
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
+        "items": {
+          "type": "array"
+        },
+        "tls": {
+          "anyOf": [
+            {
+              "$ref": "#/$defs/ServerTls"
+            },
+            {
+              "type": "null"
+            }
+          ]
+        },
+        "extra": {}
+      },
+      "required": [
+        "port",
+        "tags",
+        "extra"
+      ],
+      "additionalProperties": false
+    },
+    "ServerTls": {
+      "type": "object",
+      "properties": {
+        "cert": {
+          "type": "string",
+          "contentEncoding": "base64"
+        },
+        "hosts": {
+          "type": "object",
+          "additionalProperties": {
+            "type": "null"
+          }
+        }
+      },
+      "required": [
+        "cert",
+        "hosts"
+      ],
+      "additionalProperties": false
+    }
+  }
+}
+`
+
+	data, err := Generate(s)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(data) != expected {
+		t.Errorf("Generate() mismatch:\nExpected:\n%s\nGot:\n%s", expected, data)
+	}
+}
+
+func TestGenerate_Roots(t *testing.T) {
+	object := func(name string) schema.Object {
+		return schema.Object{Name: name, Fields: []schema.Field{{Key: "name", Type: schema.Type{Kind: schema.KindString}}}}
+	}
+
+	tests := []struct {
+		name     string
+		schema   schema.Schema
+		expected string
+	}{
+		{
+			name:     "single root",
+			schema:   schema.Schema{Roots: []string{"Document"}, Objects: []schema.Object{object("Document")}},
+			expected: `{"$ref":"#/$defs/Document"}`,
+		},
+		{
+			name:     "several roots",
+			schema:   schema.Schema{Roots: []string{"Document1", "Document2"}, Objects: []schema.Object{object("Document1"), object("Document2")}},
+			expected: `{"anyOf":[{"$ref":"#/$defs/Document1"},{"$ref":"#/$defs/Document2"}]}`,
+		},
+		{
+			name:     "no roots",
+			schema:   schema.Schema{Roots: []string{}},
+			expected: `{}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Generate(tt.schema)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			// The definitions are covered by TestGenerate, only the
+			// document keywords are compared
+			var doc map[string]json.RawMessage
+			if err := json.Unmarshal(data, &doc); err != nil {
+				t.Fatalf("Invalid JSON: %v", err)
+			}
+			if string(doc["$schema"]) != `"`+Draft+`"` {
+				t.Errorf("$schema = %s, want %q", doc["$schema"], Draft)
+			}
+			delete(doc, "$schema")
+			delete(doc, "$defs")
+
+			result, err := json.Marshal(doc)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var compact map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(tt.expected), &compact); err != nil {
+				t.Fatalf("Invalid expected JSON: %v", err)
+			}
+			want, _ := json.Marshal(compact)
+			if string(result) != string(want) {
+				t.Errorf("Generate() document = %s, want %s", result, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,499 @@
+package literal
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/richerve/yaml2go/pkg/codegen"
+	"github.com/richerve/yaml2go/pkg/diag"
+	"github.com/richerve/yaml2go/pkg/golang"
+	"github.com/richerve/yaml2go/pkg/inference"
+	"github.com/richerve/yaml2go/pkg/naming"
+	"github.com/richerve/yaml2go/pkg/schema"
+)
+
+// Builder writes YAML values as Go expressions of the generated types. It
+// collects the imports and the pointer helper used by the expressions it
+// returned, and the values that don't fit their type.
+type Builder struct {
+	structs  map[string]codegen.StructDef
+	imports  map[string]bool
+	pointers bool
+	diags    diag.List
+}
+
+// New returns a Builder writing the values of structs.
+func New(structs []codegen.StructDef) *Builder {
+	b := &Builder{
+		structs: make(map[string]codegen.StructDef, len(structs)),
+		imports: make(map[string]bool),
+	}
+	for _, s := range structs {
+		b.structs[s.Name] = s
+	}
+
+	return b
+}
+
+// Value returns node as a value of root, one of the generated structs.
+// Composite values span several lines, indented for the top level of a
+// file. Values that don't fit their type are reported at path and written
+// as the zero value.
+func (b *Builder) Value(node ast.Node, root string, path []string) string {
+	return b.expr(node, schema.ObjectOf(root), false, path, 0, false)
+}
+
+// Imports returns the sorted import paths of the packages used by the
+// values.
+func (b *Builder) Imports() []string {
+	imports := make([]string, 0, len(b.imports))
+	for path := range b.imports {
+		imports = append(imports, path)
+	}
+	sort.Strings(imports)
+
+	return imports
+}
+
+// Pointers reports whether the values use codegen.PointerHelper.
+func (b *Builder) Pointers() bool {
+	return b.pointers
+}
+
+// Diagnostics returns the values that don't fit their type.
+func (b *Builder) Diagnostics() diag.List {
+	return b.diags
+}
+
+// expr returns node as a value of t, or a pointer to it when pointer is
+// set, depth being the indentation of the line holding it. The type of a
+// composite literal is left out when elide is set, as in the elements of a
+// slice.
+func (b *Builder) expr(node ast.Node, t schema.Type, pointer bool, path []string, depth int, elide bool) string {
+	if tag, ok := node.(*ast.TagNode); ok {
+		// Values typed any take the type forced by their tag
+		if tagType, typed := inference.TagType(tag); typed && isAny(t) {
+			t, pointer = tagType, false
+		}
+		node = tag.Value
+	}
+	if isNull(node) {
+		if _, ok := b.structs[t.Object]; ok && t.Kind == schema.KindObject && !pointer && elide {
+			return "{}"
+		}
+		return b.zero(t, pointer)
+	}
+
+	if pointer {
+		if t.Kind == schema.KindObject {
+			value := b.expr(node, t, false, path, depth, elide)
+			if elide {
+				return value
+			}
+			return "&" + value
+		}
+		b.pointers = true
+		return codegen.PointerHelper + "(" + b.expr(node, t, false, path, depth, false) + ")"
+	}
+
+	goType := golang.Type(t, false)
+	switch t.Kind {
+	case schema.KindAny, schema.KindNull:
+		return b.natural(node, path, depth)
+
+	case schema.KindBinary:
+		s, ok := stringValue(node)
+		if !ok {
+			return b.mismatch(node, t, path)
+		}
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+		if err != nil {
+			b.diags = append(b.diags, diag.New(node, path, "invalid !!binary value: %v", err))
+			return "nil"
+		}
+		return "[]byte(" + strconv.Quote(string(data)) + ")"
+
+	case schema.KindArray:
+		seq, ok := node.(*ast.SequenceNode)
+		if !ok {
+			return b.mismatch(node, t, path)
+		}
+		elem := t.Element()
+		elements := make([]string, 0, len(seq.Values))
+		for i, value := range seq.Values {
+			elements = append(elements, b.expr(value, elem, golang.Pointer(elem, elem.Nullable), indexPath(path, i), depth+1, composite(elem)))
+		}
+		return typePrefix(goType, elide) + list(elements, depth)
+
+	case schema.KindSet:
+		mapping, ok := node.(*ast.MappingNode)
+		if !ok {
+			return b.mismatch(node, t, path)
+		}
+		elements := make([]string, 0, len(mapping.Values))
+		for _, value := range mapping.Values {
+			elements = append(elements, strconv.Quote(naming.KeyName(value.Key))+": {}")
+		}
+		return typePrefix(goType, elide) + list(elements, depth)
+
+	case schema.KindMap:
+		mapping, ok := node.(*ast.MappingNode)
+		if !ok {
+			return b.mismatch(node, t, path)
+		}
+		return typePrefix(goType, elide) + b.mapping(mapping, path, depth)
+
+	case schema.KindString:
+		s, ok := stringValue(node)
+		if !ok {
+			return b.mismatch(node, t, path)
+		}
+		return strconv.Quote(s)
+
+	case schema.KindInteger:
+		return b.integer(node, path)
+
+	case schema.KindFloat:
+		return b.float(node, path)
+
+	case schema.KindBoolean:
+		switch n := node.(type) {
+		case *ast.BoolNode:
+			return strconv.FormatBool(n.Value)
+		case *ast.StringNode:
+			if v, err := strconv.ParseBool(n.Value); err == nil {
+				return strconv.FormatBool(v)
+			}
+		}
+		return b.mismatch(node, t, path)
+
+	case schema.KindTimestamp:
+		return b.time(node, path)
+	}
+
+	def, ok := b.structs[t.Object]
+	mapping, isMapping := node.(*ast.MappingNode)
+	if !ok || !isMapping {
+		return b.mismatch(node, t, path)
+	}
+
+	values := make(map[string]ast.Node, len(mapping.Values))
+	for _, value := range mapping.Values {
+		values[naming.KeyName(value.Key)] = value.Value
+	}
+
+	// Fields follow the order of the struct, the keys missing from this
+	// mapping and the null ones are left to their zero value
+	var fields []string
+	for _, field := range def.Fields {
+		value, ok := values[field.Name]
+		if !ok || isNull(value) {
+			continue
+		}
+		expr := b.expr(value, field.Schema, field.Pointer, append(path[:len(path):len(path)], field.Name), depth+1, false)
+		fields = append(fields, field.FieldName()+": "+expr)
+	}
+
+	return typePrefix(goType, elide) + block(fields, depth)
+}
+
+// natural returns node as the value a YAML decoder stores in an any: a
+// string, an int, a float64, a bool, a map[string]any or a []any.
+func (b *Builder) natural(node ast.Node, path []string, depth int) string {
+	switch n := node.(type) {
+	case *ast.StringNode, *ast.LiteralNode:
+		s, _ := stringValue(n)
+		return strconv.Quote(s)
+
+	case *ast.IntegerNode:
+		// Values beyond an int only fit in an uint64
+		if v, ok := n.Value.(uint64); ok && v > math.MaxInt64 {
+			return fmt.Sprintf("uint64(%d)", v)
+		}
+		return fmt.Sprint(n.Value)
+
+	case *ast.FloatNode:
+		return floatLiteral(n.Value)
+
+	case *ast.InfinityNode:
+		return b.floatExpr(n.Value)
+
+	case *ast.NanNode:
+		return b.floatExpr(math.NaN())
+
+	case *ast.BoolNode:
+		return strconv.FormatBool(n.Value)
+
+	case *ast.MappingNode:
+		return "map[string]any" + b.mapping(n, path, depth)
+
+	case *ast.SequenceNode:
+		elements := make([]string, 0, len(n.Values))
+		for i, value := range n.Values {
+			elements = append(elements, b.expr(value, anyType, false, indexPath(path, i), depth+1, false))
+		}
+		return "[]any" + list(elements, depth)
+
+	default:
+		return b.mismatch(node, anyType, path)
+	}
+}
+
+// mapping returns the body of a map[string]any literal holding node.
+func (b *Builder) mapping(node *ast.MappingNode, path []string, depth int) string {
+	entries := make([]string, 0, len(node.Values))
+	for _, value := range node.Values {
+		key := naming.KeyName(value.Key)
+		expr := b.expr(value.Value, anyType, false, append(path[:len(path):len(path)], key), depth+1, false)
+		entries = append(entries, strconv.Quote(key)+": "+expr)
+	}
+
+	return block(entries, depth)
+}
+
+func (b *Builder) integer(node ast.Node, path []string) string {
+	switch n := node.(type) {
+	case *ast.IntegerNode:
+		if v, ok := n.Value.(uint64); ok && v > math.MaxInt64 {
+			b.diags = append(b.diags, diag.New(node, path, "value %d overflows int", v))
+			return "0"
+		}
+		return fmt.Sprint(n.Value)
+
+	case *ast.StringNode:
+		// A string tagged !!int
+		if v, err := strconv.ParseInt(strings.ReplaceAll(n.Value, "_", ""), 0, 64); err == nil {
+			return strconv.FormatInt(v, 10)
+		}
+	}
+
+	return b.mismatch(node, schema.Type{Kind: schema.KindInteger}, path)
+}
+
+func (b *Builder) float(node ast.Node, path []string) string {
+	switch n := node.(type) {
+	case *ast.FloatNode:
+		return floatLiteral(n.Value)
+
+	case *ast.InfinityNode:
+		return b.floatExpr(n.Value)
+
+	case *ast.NanNode:
+		return b.floatExpr(math.NaN())
+
+	case *ast.IntegerNode:
+		// Integers merged with floats into a float64
+		switch v := n.Value.(type) {
+		case int64:
+			return floatLiteral(float64(v))
+		case uint64:
+			return floatLiteral(float64(v))
+		}
+
+	case *ast.StringNode:
+		// A string tagged !!float
+		if v, err := strconv.ParseFloat(strings.ReplaceAll(n.Value, "_", ""), 64); err == nil {
+			return b.floatExpr(v)
+		}
+	}
+
+	return b.mismatch(node, schema.Type{Kind: schema.KindFloat}, path)
+}
+
+var (
+	anyType       = schema.Type{Kind: schema.KindAny}
+	timestampType = schema.Type{Kind: schema.KindTimestamp}
+)
+
+// timestampFormats are the forms of !!timestamp values understood by the
+// YAML decoder, see http://yaml.org/type/timestamp.html.
+var timestampFormats = []string{
+	"2006-1-2T15:4:5.999999999Z07:00",
+	"2006-1-2t15:4:5.999999999Z07:00",
+	"2006-1-2 15:4:5.999999999",
+	"2006-1-2",
+}
+
+func (b *Builder) time(node ast.Node, path []string) string {
+	s, ok := stringValue(node)
+	if !ok {
+		return b.mismatch(node, timestampType, path)
+	}
+
+	for _, format := range timestampFormats {
+		t, err := time.Parse(format, s)
+		if err != nil {
+			continue
+		}
+
+		b.imports["time"] = true
+		location := "time.UTC"
+		if _, offset := t.Zone(); offset != 0 {
+			location = fmt.Sprintf("time.FixedZone(\"\", %d)", offset)
+		}
+		return fmt.Sprintf("time.Date(%d, time.%s, %d, %d, %d, %d, %d, %s)",
+			t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), location)
+	}
+
+	b.diags = append(b.diags, diag.New(node, path, "invalid !!timestamp value %q", s))
+	return b.zero(timestampType, false)
+}
+
+// zero returns the zero value of t, or of a pointer to it when pointer is
+// set.
+func (b *Builder) zero(t schema.Type, pointer bool) string {
+	if pointer {
+		return "nil"
+	}
+
+	switch t.Kind {
+	case schema.KindString:
+		return `""`
+	case schema.KindInteger, schema.KindFloat:
+		return "0"
+	case schema.KindBoolean:
+		return "false"
+	case schema.KindTimestamp:
+		b.imports["time"] = true
+		return "time.Time{}"
+	case schema.KindObject:
+		return t.Object + "{}"
+	default:
+		return "nil"
+	}
+}
+
+// mismatch reports a value of node that doesn't fit t and returns the zero
+// value in its place.
+func (b *Builder) mismatch(node ast.Node, t schema.Type, path []string) string {
+	b.diags = append(b.diags, diag.New(node, path, "%s value doesn't fit type %s", node.Type(), golang.Type(t, false)))
+	return b.zero(t, false)
+}
+
+// stringValue returns the text of a scalar node, as written for the ones
+// that are not strings, e.g. a number tagged !!str.
+func stringValue(node ast.Node) (string, bool) {
+	switch n := node.(type) {
+	case *ast.StringNode:
+		return n.Value, true
+	case *ast.LiteralNode:
+		return n.Value.Value, true
+	case *ast.IntegerNode, *ast.FloatNode, *ast.BoolNode:
+		return n.GetToken().Value, true
+	default:
+		return "", false
+	}
+}
+
+// floatLiteral returns f as a floating-point constant, so that it stays a
+// float64 when its type is inferred, e.g. 1.0 rather than 1.
+func floatLiteral(f float64) string {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+
+	return s
+}
+
+// floatExpr returns f as a float64 expression, calling math for the
+// infinities and NaN that have no constant.
+func (b *Builder) floatExpr(f float64) string {
+	var expr string
+	switch {
+	case math.IsInf(f, 1):
+		expr = "math.Inf(1)"
+	case math.IsInf(f, -1):
+		expr = "math.Inf(-1)"
+	case math.IsNaN(f):
+		expr = "math.NaN()"
+	default:
+		return floatLiteral(f)
+	}
+	b.imports["math"] = true
+
+	return expr
+}
+
+func isNull(node ast.Node) bool {
+	if tag, ok := node.(*ast.TagNode); ok {
+		node = tag.Value
+	}
+	_, null := node.(*ast.NullNode)
+
+	return null
+}
+
+// isAny reports whether the values of t are written as a Go any.
+func isAny(t schema.Type) bool {
+	return t.Kind == schema.KindAny || t.Kind == schema.KindNull
+}
+
+// composite reports whether values of t are written as composite literals,
+// whose type can be left out in the elements of a slice.
+func composite(t schema.Type) bool {
+	switch t.Kind {
+	case schema.KindObject, schema.KindArray, schema.KindSet, schema.KindMap:
+		return true
+	default:
+		return false
+	}
+}
+
+func typePrefix(goType string, elide bool) string {
+	if elide {
+		return ""
+	}
+
+	return goType
+}
+
+// list returns the braces holding elements, on a single line unless one of
+// them spans several.
+func list(elements []string, depth int) string {
+	for _, element := range elements {
+		if strings.Contains(element, "\n") {
+			return block(elements, depth)
+		}
+	}
+
+	return "{" + strings.Join(elements, ", ") + "}"
+}
+
+// block returns the braces holding elements, one per line indented below
+// depth.
+func block(elements []string, depth int) string {
+	if len(elements) == 0 {
+		return "{}"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("{\n")
+	for _, element := range elements {
+		sb.WriteString(strings.Repeat("\t", depth+1))
+		sb.WriteString(element)
+		sb.WriteString(",\n")
+	}
+	sb.WriteString(strings.Repeat("\t", depth))
+	sb.WriteString("}")
+
+	return sb.String()
+}
+
+// indexPath returns path with the index i of a sequence element appended
+// to its last key, e.g. Document.items[2].
+func indexPath(path []string, i int) []string {
+	result := append([]string(nil), path...)
+	if len(result) == 0 {
+		return []string{fmt.Sprintf("[%d]", i)}
+	}
+	result[len(result)-1] += fmt.Sprintf("[%d]", i)
+
+	return result
+}
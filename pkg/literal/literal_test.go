@@ -0,0 +1,274 @@
+package literal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml/parser"
+	"github.com/richerve/yaml2go/pkg/golang"
+	"github.com/richerve/yaml2go/pkg/inference"
+	"github.com/richerve/yaml2go/pkg/schema"
+)
+
+func TestBuilder_Value(t *testing.T) {
+	str := schema.Type{Kind: schema.KindString}
+	integer := schema.Type{Kind: schema.KindInteger}
+	structs, _ := golang.Structs(schema.Schema{
+		Objects: []schema.Object{
+			{
+				Name: "Config",
+				Fields: []schema.Field{
+					{Key: "name", Type: str, Optional: true},
+					{Key: "port", Type: integer},
+					{Key: "ratio", Type: schema.Type{Kind: schema.KindFloat}, Optional: true},
+					{Key: "servers", Type: schema.ArrayOf(schema.ObjectOf("Server"))},
+					{Key: "tls", Type: schema.Type{Kind: schema.KindObject, Object: "TLS", Nullable: true}, Optional: true},
+					{Key: "extra", Type: schema.Type{Kind: schema.KindAny}},
+					{Key: "backups", Type: schema.ArrayOf(schema.Type{Kind: schema.KindObject, Object: "Server", Nullable: true})},
+					{Key: "weights", Type: schema.ArrayOf(schema.Type{Kind: schema.KindInteger, Nullable: true})},
+				},
+			},
+			{
+				Name: "Server",
+				Fields: []schema.Field{
+					{Key: "host", Type: str},
+					{Key: "ports", Type: schema.ArrayOf(integer)},
+				},
+			},
+			{
+				Name:   "TLS",
+				Fields: []schema.Field{{Key: "cert_file", Type: str}},
+			},
+			{
+				Name: "Tagged",
+				Fields: []schema.Field{
+					{Key: "created", Type: schema.Type{Kind: schema.KindTimestamp}, Optional: true},
+					{Key: "data", Type: schema.Type{Kind: schema.KindBinary}},
+					{Key: "set", Type: schema.Type{Kind: schema.KindSet}},
+					{Key: "id", Type: str},
+				},
+			},
+		},
+	}, golang.Options{Pointers: inference.PointerOptional})
+
+	tests := []struct {
+		name             string
+		input            string
+		root             string
+		expected         string
+		expectedImports  string
+		expectedPointers bool
+		expectedDiags    []string
+	}{
+		{
+			name: "struct with nested values",
+			input: `
+name: app
+port: 80
+ratio: 2
+servers:
+  - host: a
+    ports: [80, 443]
+  - null
+tls:
+  cert_file: cert.pem
+`,
+			root: "Config",
+			expected: `Config{
+	Name: ptr("app"),
+	Port: 80,
+	Ratio: ptr(2.0),
+	Servers: []Server{
+		{
+			Host: "a",
+			Ports: []int{80, 443},
+		},
+		{},
+	},
+	TLS: &TLS{
+		CertFile: "cert.pem",
+	},
+}`,
+			expectedPointers: true,
+		},
+		{
+			name: "nullable elements are pointers",
+			input: `
+backups:
+  - host: b
+  - null
+weights: [1, null]
+`,
+			root: "Config",
+			expected: `Config{
+	Backups: []*Server{
+		{
+			Host: "b",
+		},
+		nil,
+	},
+	Weights: []*int{ptr(1), nil},
+}`,
+			expectedPointers: true,
+		},
+		{
+			name: "missing and null keys are left out",
+			input: `
+name: null
+port: 8080
+`,
+			root: "Config",
+			expected: `Config{
+	Port: 8080,
+}`,
+		},
+		{
+			name: "infinities and NaN",
+			input: `
+ratio: .inf
+extra: [-.inf, .nan, !!float inf]
+`,
+			root: "Config",
+			expected: `Config{
+	Ratio: ptr(math.Inf(1)),
+	Extra: []any{math.Inf(-1), math.NaN(), math.Inf(1)},
+}`,
+			expectedImports:  "math",
+			expectedPointers: true,
+		},
+		{
+			name: "any holds the decoded form",
+			input: `
+extra:
+  list: [1, two, 3.0, true, null]
+  nested:
+    key: value
+`,
+			root: "Config",
+			expected: `Config{
+	Extra: map[string]any{
+		"list": []any{1, "two", 3.0, true, nil},
+		"nested": map[string]any{
+			"key": "value",
+		},
+	},
+}`,
+		},
+		{
+			name: "explicit tags",
+			input: `
+created: !!timestamp 2001-12-14t21:59:43.10-05:00
+data: !!binary aGVsbG8=
+set: !!set {a, b}
+id: !!str 123
+`,
+			root: "Tagged",
+			expected: `Tagged{
+	Created: ptr(time.Date(2001, time.December, 14, 21, 59, 43, 100000000, time.FixedZone("", -18000))),
+	Data: []byte("hello"),
+	Set: map[string]struct{}{"a": {}, "b": {}},
+	ID: "123",
+}`,
+			expectedImports:  "time",
+			expectedPointers: true,
+		},
+		{
+			name: "block set",
+			input: `
+set: !!set
+  ? a
+  ? b
+`,
+			root: "Tagged",
+			expected: `Tagged{
+	Set: map[string]struct{}{"a": {}, "b": {}},
+}`,
+		},
+		{
+			name:            "timestamp in an any",
+			input:           `extra: !!timestamp 2024-02-03`,
+			root:            "Config",
+			expected:        "Config{\n\tExtra: time.Date(2024, time.February, 3, 0, 0, 0, 0, time.UTC),\n}",
+			expectedImports: "time",
+		},
+		{
+			name:     "integer beyond int in an any",
+			input:    `extra: 18446744073709551615`,
+			root:     "Config",
+			expected: "Config{\n\tExtra: uint64(18446744073709551615),\n}",
+		},
+		{
+			name:          "integer beyond int",
+			input:         `port: 18446744073709551615`,
+			root:          "Config",
+			expected:      "Config{\n\tPort: 0,\n}",
+			expectedDiags: []string{"1:7: Config.port: value 18446744073709551615 overflows int"},
+		},
+		{
+			name: "value not fitting its type",
+			input: `
+servers:
+  - host: a
+    ports: 80
+`,
+			root: "Config",
+			expected: `Config{
+	Servers: []Server{
+		{
+			Host: "a",
+			Ports: nil,
+		},
+	},
+}`,
+			expectedDiags: []string{"4:12: Config.servers[0].ports: Integer value doesn't fit type []int"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte(tt.input), 0)
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			b := New(structs)
+			result := b.Value(file.Docs[0].Body, tt.root, []string{tt.root})
+			if result != tt.expected {
+				t.Errorf("Value() = %v, want %v", result, tt.expected)
+			}
+			if imports := strings.Join(b.Imports(), ","); imports != tt.expectedImports {
+				t.Errorf("Imports() = %v, want %v", imports, tt.expectedImports)
+			}
+			if b.Pointers() != tt.expectedPointers {
+				t.Errorf("Pointers() = %v, want %v", b.Pointers(), tt.expectedPointers)
+			}
+
+			var diags []string
+			for _, d := range b.Diagnostics() {
+				diags = append(diags, d.Error())
+			}
+			if strings.Join(diags, "\n") != strings.Join(tt.expectedDiags, "\n") {
+				t.Errorf("Diagnostics() = %v, want %v", diags, tt.expectedDiags)
+			}
+		})
+	}
+}
+
+func TestFloatLiteral(t *testing.T) {
+	tests := []struct {
+		value    float64
+		expected string
+	}{
+		{value: 1, expected: "1.0"},
+		{value: 1.5, expected: "1.5"},
+		{value: -0.25, expected: "-0.25"},
+		{value: 1e21, expected: "1e+21"},
+		{value: 1e-7, expected: "1e-07"},
+	}
+
+	for _, tt := range tests {
+		if result := floatLiteral(tt.value); result != tt.expected {
+			t.Errorf("floatLiteral(%v) = %v, want %v", tt.value, result, tt.expected)
+		}
+	}
+}
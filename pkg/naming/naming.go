@@ -0,0 +1,429 @@
+package naming
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/richerve/yaml2go/pkg/codegen"
+	"github.com/richerve/yaml2go/pkg/ident"
+)
+
+// Strategy selects how struct names that collide are made unique.
+type Strategy string
+
+const (
+	// Parent prefixes colliding struct names with the keys of their parents
+	// until every name is unique, e.g. ProductsSettings and ServerSettings.
+	Parent Strategy = "parent"
+	// Merge shares a single struct between colliding paths with an identical
+	// shape, the remaining collisions are qualified as with Parent.
+	Merge Strategy = "merge"
+)
+
+// String and Set let a Strategy be used as a command line flag value.
+func (s *Strategy) String() string {
+	return string(*s)
+}
+
+func (s *Strategy) Set(value string) error {
+	strategy, err := ParseStrategy(value)
+	if err != nil {
+		return err
+	}
+
+	*s = strategy
+	return nil
+}
+
+func ParseStrategy(s string) (Strategy, error) {
+	switch Strategy(s) {
+	case Parent, Merge:
+		return Strategy(s), nil
+	default:
+		return "", fmt.Errorf("unknown naming strategy %q, expected %q or %q", s, Parent, Merge)
+	}
+}
+
+// pathSeparator joins path elements into registry keys, YAML keys can hold
+// any printable character so a control character is used.
+const pathSeparator = "\x00"
+
+type entry struct {
+	path []string
+	// element is set when the last path element was derived from the key of
+	// the enclosing sequence, see codegen.ElementName.
+	element bool
+	fields  map[string]string
+}
+
+// Registry assigns a unique struct name to every YAML path holding a
+// non-empty mapping. All documents are collected first so that collisions
+// are resolved the same way regardless of the order the structs are visited.
+type Registry struct {
+	strategy Strategy
+	order    []string
+	entries  map[string]*entry
+	names    map[string]string
+	// nodes holds the key of the path each mapping was first collected at,
+	// aliases maps the paths reaching the same mapping again through a YAML
+	// alias to that key so they share its struct
+	nodes   map[*ast.MappingNode]string
+	aliases map[string]string
+}
+
+func NewRegistry(strategy Strategy) *Registry {
+	if strategy == "" {
+		strategy = Parent
+	}
+
+	return &Registry{
+		strategy: strategy,
+		entries:  make(map[string]*entry),
+		names:    make(map[string]string),
+		nodes:    make(map[*ast.MappingNode]string),
+		aliases:  make(map[string]string),
+	}
+}
+
+// Collect records the struct paths found in node, rooted at path.
+func (r *Registry) Collect(node ast.Node, path []string) {
+	r.collect(node, path, false)
+}
+
+// collect registers the mappings under node and returns its shape, a
+// canonical description of the keys and value kinds used to detect
+// identical structs.
+func (r *Registry) collect(node ast.Node, path []string, element bool) string {
+	switch n := node.(type) {
+	case *ast.DocumentNode:
+		return r.collect(n.Body, path, element)
+
+	case *ast.MappingNode:
+		if len(n.Values) == 0 {
+			return "{}"
+		}
+
+		fields := make(map[string]string)
+		for _, mappingValue := range n.Values {
+			key := KeyName(mappingValue.Key)
+			fields[key] = r.collect(mappingValue.Value, appendPath(path, key), false)
+		}
+
+		key := strings.Join(path, pathSeparator)
+		if first, seen := r.nodes[n]; seen && first != key {
+			r.aliases[key] = first
+		} else {
+			r.nodes[n] = key
+			r.add(path, element, fields)
+		}
+
+		return shape(fields)
+
+	case *ast.SequenceNode:
+		seen := make(map[string]bool)
+		for _, value := range n.Values {
+			if tag, ok := value.(*ast.TagNode); ok && !isSet(tag) {
+				value = tag.Value
+			}
+			switch value.(type) {
+			case *ast.MappingNode:
+				seen[r.collect(value, appendPath(path, codegen.ElementName(last(path))), true)] = true
+			default:
+				seen[r.collect(value, path, element)] = true
+			}
+		}
+
+		var elements []string
+		for s := range seen {
+			elements = append(elements, s)
+		}
+		sort.Strings(elements)
+
+		return "[" + strings.Join(elements, "|") + "]"
+
+	case *ast.TagNode:
+		if isSet(n) {
+			// Sets are maps of keys, not structs
+			return "set"
+		}
+		return r.collect(n.Value, path, element)
+
+	case *ast.StringNode, *ast.LiteralNode:
+		return "string"
+	case *ast.IntegerNode:
+		return "int"
+	case *ast.FloatNode, *ast.InfinityNode, *ast.NanNode:
+		return "float"
+	case *ast.BoolNode:
+		return "bool"
+	case *ast.NullNode:
+		return "null"
+	default:
+		return "any"
+	}
+}
+
+func (r *Registry) add(path []string, element bool, fields map[string]string) {
+	key := strings.Join(path, pathSeparator)
+	e, exists := r.entries[key]
+	if !exists {
+		e = &entry{
+			path:    path,
+			element: element,
+			fields:  make(map[string]string),
+		}
+		r.entries[key] = e
+		r.order = append(r.order, key)
+	}
+
+	// The same path seen again (sequence elements, repeated documents)
+	// describes the same struct, keep the union of the keys
+	for k, s := range fields {
+		if _, ok := e.fields[k]; !ok {
+			e.fields[k] = s
+		}
+	}
+}
+
+// Resolve assigns the final struct names. Colliding paths get their parent
+// keys prepended one level at a time; collisions that remain once every
+// parent has been used are numbered in collection order. With the Merge
+// strategy paths with the same name and shape are resolved as one, using
+// the first of them collected.
+func (r *Registry) Resolve() {
+	keys, members := r.classes()
+	depth := make(map[string]int)
+
+	for {
+		changed := false
+		for _, group := range r.groups(keys, depth) {
+			if len(group) < 2 {
+				continue
+			}
+			for _, key := range group {
+				if depth[key] < r.maxDepth(key) {
+					depth[key]++
+					changed = true
+				}
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	taken := make(map[string]bool)
+	for _, key := range keys {
+		taken[r.candidate(key, depth[key])] = true
+	}
+
+	groups := r.groups(keys, depth)
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		group := groups[name]
+		r.assign(members[group[0]], name)
+
+		suffix := 2
+		for _, key := range group[1:] {
+			for taken[fmt.Sprintf("%s%d", name, suffix)] {
+				suffix++
+			}
+			numbered := fmt.Sprintf("%s%d", name, suffix)
+			taken[numbered] = true
+			r.assign(members[key], numbered)
+		}
+	}
+}
+
+// classes returns the keys to resolve, in collection order, and the paths
+// each of them names.
+func (r *Registry) classes() ([]string, map[string][]string) {
+	var keys []string
+	members := make(map[string][]string)
+	representative := make(map[string]string)
+
+	for _, key := range r.order {
+		class := key
+		if r.strategy == Merge {
+			class = r.candidate(key, 0) + pathSeparator + shape(r.entries[key].fields)
+		}
+
+		rep, exists := representative[class]
+		if !exists {
+			rep = key
+			representative[class] = key
+			keys = append(keys, key)
+		}
+		members[rep] = append(members[rep], key)
+	}
+
+	return keys, members
+}
+
+func (r *Registry) assign(keys []string, name string) {
+	for _, key := range keys {
+		r.names[key] = name
+	}
+}
+
+// StructName returns the struct name resolved for path, paths reaching an
+// anchored mapping through an alias get the name of the anchor's path.
+// Paths that were not collected, or a nil registry, fall back to the
+// capitalized last key.
+func (r *Registry) StructName(path []string) string {
+	if r != nil {
+		key := strings.Join(path, pathSeparator)
+		if first, ok := r.aliases[key]; ok {
+			key = first
+		}
+		if name, ok := r.names[key]; ok {
+			return name
+		}
+	}
+
+	if len(path) == 0 {
+		return "Document"
+	}
+
+	return ident.Exported(last(path))
+}
+
+// groups returns keys grouped by their candidate name at the given depth,
+// each group in collection order.
+func (r *Registry) groups(keys []string, depth map[string]int) map[string][]string {
+	groups := make(map[string][]string)
+	for _, key := range keys {
+		name := r.candidate(key, depth[key])
+		groups[name] = append(groups[name], key)
+	}
+
+	return groups
+}
+
+// candidate returns the name of key qualified with depth parent keys. The
+// depth past the qualifiers of key qualifies it with every parent key.
+func (r *Registry) candidate(key string, depth int) string {
+	e := r.entries[key]
+	qualifiers := r.qualifiers(key)
+	if depth > len(qualifiers) {
+		qualifiers = reversed(e.path[:len(e.path)-1])
+		depth = len(qualifiers)
+	}
+
+	var name strings.Builder
+	for i := depth - 1; i >= 0; i-- {
+		name.WriteString(ident.Exported(qualifiers[i]))
+	}
+	name.WriteString(ident.Exported(last(e.path)))
+
+	return name.String()
+}
+
+// qualifiers returns the parent keys usable to disambiguate key, nearest
+// first. The keys of the enclosing sequences are skipped as the element
+// names are already derived from them, for the element and the mappings
+// below it alike.
+func (r *Registry) qualifiers(key string) []string {
+	e := r.entries[key]
+	parents := e.path[:len(e.path)-1]
+	if e.element && len(parents) > 0 {
+		parents = parents[:len(parents)-1]
+	}
+
+	qualifiers := make([]string, 0, len(parents))
+	for i := len(parents) - 1; i >= 0; i-- {
+		qualifiers = append(qualifiers, parents[i])
+		if i > 0 && r.isElement(parents[:i+1]) {
+			i--
+		}
+	}
+
+	return qualifiers
+}
+
+// maxDepth returns the number of qualifiers key can take: its qualifiers,
+// and every parent key when a sequence key was skipped.
+func (r *Registry) maxDepth(key string) int {
+	e := r.entries[key]
+	depth := len(r.qualifiers(key))
+	if depth < len(e.path)-1 {
+		depth++
+	}
+
+	return depth
+}
+
+// isElement reports whether path is a mapping element of a sequence.
+func (r *Registry) isElement(path []string) bool {
+	e, ok := r.entries[strings.Join(path, pathSeparator)]
+	return ok && e.element
+}
+
+func reversed(path []string) []string {
+	result := make([]string, 0, len(path))
+	for i := len(path) - 1; i >= 0; i-- {
+		result = append(result, path[i])
+	}
+
+	return result
+}
+
+func shape(fields map[string]string) string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var s strings.Builder
+	s.WriteString("{")
+	for i, k := range keys {
+		if i > 0 {
+			s.WriteString(",")
+		}
+		fmt.Fprintf(&s, "%q:%s", k, fields[k])
+	}
+	s.WriteString("}")
+
+	return s.String()
+}
+
+// KeyName returns the text of a mapping key as written in the YAML, unquoted
+// and without the comments that String would include. Explicit keys, such as
+// the elements of a block !!set written ? a, are the text after the ?.
+func KeyName(key ast.MapKeyNode) string {
+	if explicit, ok := key.(*ast.MappingKeyNode); ok {
+		if value, ok := explicit.Value.(ast.MapKeyNode); ok {
+			key = value
+		}
+	}
+	if tk := key.GetToken(); tk != nil {
+		return tk.Value
+	}
+
+	return key.String()
+}
+
+func isSet(node *ast.TagNode) bool {
+	return node.Start.Value == "!!set" || node.Start.Value == "!<tag:yaml.org,2002:set>"
+}
+
+func appendPath(path []string, key string) []string {
+	newPath := make([]string, len(path), len(path)+1)
+	copy(newPath, path)
+	return append(newPath, key)
+}
+
+func last(path []string) string {
+	if len(path) == 0 {
+		return ""
+	}
+	return path[len(path)-1]
+}
@@ -0,0 +1,371 @@
+package naming_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/richerve/yaml2go/pkg/naming"
+	"github.com/richerve/yaml2go/pkg/resolve"
+)
+
+func TestParseStrategy(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    naming.Strategy
+		expectError bool
+	}{
+		{
+			name:     "parent",
+			input:    "parent",
+			expected: naming.Parent,
+		},
+		{
+			name:     "merge",
+			input:    "merge",
+			expected: naming.Merge,
+		},
+		{
+			name:        "unknown",
+			input:       "last",
+			expectError: true,
+		},
+		{
+			name:        "empty",
+			input:       "",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := naming.ParseStrategy(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error for %q, got none", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("ParseStrategy(%q) = %v, want %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStrategy_Set(t *testing.T) {
+	var s naming.Strategy
+	if err := s.Set("merge"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s != naming.Merge {
+		t.Errorf("Set(\"merge\") = %v, want %v", s, naming.Merge)
+	}
+
+	if err := s.Set("last"); err == nil {
+		t.Errorf("Expected an error for an unknown strategy")
+	}
+	if s.String() != "merge" {
+		t.Errorf("Expected the strategy to be unchanged after an error, got %v", s)
+	}
+}
+
+func TestRegistry_Resolve(t *testing.T) {
+	tests := []struct {
+		name      string
+		yamlInput string
+		strategy  naming.Strategy
+		expected  map[string][][]string
+	}{
+		{
+			name: "no collisions keep the last key",
+			yamlInput: `
+server:
+  settings:
+    port: 8080
+`,
+			strategy: naming.Parent,
+			expected: map[string][][]string{
+				"Server":   {{"Document", "server"}},
+				"Settings": {{"Document", "server", "settings"}},
+			},
+		},
+		{
+			name: "collisions qualified by parent",
+			yamlInput: `
+server:
+  settings:
+    port: 8080
+products:
+  settings:
+    charset: utf8
+`,
+			strategy: naming.Parent,
+			expected: map[string][][]string{
+				"ServerSettings":   {{"Document", "server", "settings"}},
+				"ProductsSettings": {{"Document", "products", "settings"}},
+			},
+		},
+		{
+			name: "collisions qualified until unique",
+			yamlInput: `
+app:
+  server:
+    settings:
+      port: 8080
+db:
+  server:
+    settings:
+      host: localhost
+`,
+			strategy: naming.Parent,
+			expected: map[string][][]string{
+				"AppServer":         {{"Document", "app", "server"}},
+				"DbServer":          {{"Document", "db", "server"}},
+				"AppServerSettings": {{"Document", "app", "server", "settings"}},
+				"DbServerSettings":  {{"Document", "db", "server", "settings"}},
+			},
+		},
+		{
+			name: "root keeps its name",
+			yamlInput: `
+user:
+  name: john
+`,
+			strategy: naming.Parent,
+			expected: map[string][][]string{
+				"Document": {{"Document"}},
+				"User":     {{"Document", "user"}},
+			},
+		},
+		{
+			name: "collision with root name",
+			yamlInput: `
+document:
+  name: john
+`,
+			strategy: naming.Parent,
+			expected: map[string][][]string{
+				"Document":         {{"Document"}},
+				"DocumentDocument": {{"Document", "document"}},
+			},
+		},
+		{
+			name: "sequence elements skip the sequence key",
+			yamlInput: `
+web:
+  items:
+    - name: a
+db:
+  items:
+    - size: 1
+`,
+			strategy: naming.Parent,
+			expected: map[string][][]string{
+				"WebItem": {{"Document", "web", "items", "item"}},
+				"DbItem":  {{"Document", "db", "items", "item"}},
+			},
+		},
+		{
+			name: "elements qualified with the sequence key last",
+			yamlInput: `
+servers:
+  - settings:
+      port: 80
+server:
+  settings:
+    host: a
+`,
+			strategy: naming.Parent,
+			expected: map[string][][]string{
+				"DocumentServersServer":         {{"Document", "servers", "server"}},
+				"DocumentServer":                {{"Document", "server"}},
+				"DocumentServersServerSettings": {{"Document", "servers", "server", "settings"}},
+				"DocumentServerSettings":        {{"Document", "server", "settings"}},
+			},
+		},
+		{
+			name: "mappings below elements skip the sequence key",
+			yamlInput: `
+servers:
+  - settings:
+      port: 80
+proxy:
+  settings:
+    host: a
+`,
+			strategy: naming.Parent,
+			expected: map[string][][]string{
+				"Server":         {{"Document", "servers", "server"}},
+				"ServerSettings": {{"Document", "servers", "server", "settings"}},
+				"ProxySettings":  {{"Document", "proxy", "settings"}},
+			},
+		},
+		{
+			name: "identical shapes merged",
+			yamlInput: `
+server:
+  settings:
+    port: 8080
+proxy:
+  settings:
+    port: 9090
+`,
+			strategy: naming.Merge,
+			expected: map[string][][]string{
+				"Settings": {
+					{"Document", "server", "settings"},
+					{"Document", "proxy", "settings"},
+				},
+			},
+		},
+		{
+			name: "different shapes qualified when merging",
+			yamlInput: `
+server:
+  settings:
+    port: 8080
+products:
+  settings:
+    charset: utf8
+`,
+			strategy: naming.Merge,
+			expected: map[string][][]string{
+				"ServerSettings":   {{"Document", "server", "settings"}},
+				"ProductsSettings": {{"Document", "products", "settings"}},
+			},
+		},
+		{
+			name: "exhausted parents are numbered",
+			yamlInput: `
+a:
+  b: {x: 1}
+b: {z: 1}
+a_b: {y: 1}
+`,
+			strategy: naming.Parent,
+			expected: map[string][][]string{
+				"DocumentAB":  {{"Document", "a", "b"}},
+				"DocumentB":   {{"Document", "b"}},
+				"DocumentAB2": {{"Document", "a_b"}},
+			},
+		},
+		{
+			name: "aliases share the anchor's struct",
+			yamlInput: `
+base: &base
+  pool:
+    size: 5
+copy: *base
+`,
+			strategy: naming.Parent,
+			expected: map[string][][]string{
+				"Base": {{"Document", "base"}, {"Document", "copy"}},
+				"Pool": {{"Document", "base", "pool"}, {"Document", "copy", "pool"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte(tt.yamlInput), 0)
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			doc, _ := resolve.Document(file.Docs[0])
+			r := naming.NewRegistry(tt.strategy)
+			r.Collect(doc, []string{"Document"})
+			r.Resolve()
+
+			for expected, paths := range tt.expected {
+				for _, path := range paths {
+					if result := r.StructName(path); result != expected {
+						t.Errorf("StructName(%v) = %v, want %v", path, result, expected)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestKeyName(t *testing.T) {
+	tests := []struct {
+		name      string
+		yamlInput string
+		expected  []string
+	}{
+		{name: "plain keys", yamlInput: "name: a\nport: 80\n", expected: []string{"name", "port"}},
+		{name: "quoted key", yamlInput: "\"a b\": 1\n", expected: []string{"a b"}},
+		{name: "explicit key", yamlInput: "? foo\n: 1\n", expected: []string{"foo"}},
+		{name: "block set", yamlInput: "!!set\n? a\n? b\n", expected: []string{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte(tt.yamlInput), 0)
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			body := file.Docs[0].Body
+			if tag, ok := body.(*ast.TagNode); ok {
+				body = tag.Value
+			}
+			mapping, ok := body.(*ast.MappingNode)
+			if !ok {
+				t.Fatalf("Expected a mapping, got %T", body)
+			}
+
+			var result []string
+			for _, value := range mapping.Values {
+				result = append(result, naming.KeyName(value.Key))
+			}
+			if strings.Join(result, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("KeyName() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRegistry_StructName_Fallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		registry *naming.Registry
+		path     []string
+		expected string
+	}{
+		{
+			name:     "nil registry",
+			registry: nil,
+			path:     []string{"app", "user_profile"},
+			expected: "UserProfile",
+		},
+		{
+			name:     "empty path",
+			registry: nil,
+			path:     []string{},
+			expected: "Document",
+		},
+		{
+			name:     "path not collected",
+			registry: naming.NewRegistry(naming.Parent),
+			path:     []string{"app", "database"},
+			expected: "Database",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.registry.StructName(tt.path)
+			if result != tt.expected {
+				t.Errorf("StructName(%v) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/richerve/yaml2go/pkg/diff"
+)
+
+// WriteFile writes data to name atomically: the data is written to a
+// temporary file in the same directory which then replaces name, so readers
+// never see a partial file. A file already holding data is left untouched,
+// keeping its modification time for go generate and build caches. It
+// reports whether the file was written.
+func WriteFile(name string, data []byte) (bool, error) {
+	existing, err := os.ReadFile(name)
+	if err == nil && bytes.Equal(existing, data) {
+		return false, nil
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return false, err
+	}
+	// Removing fails once the file is renamed, only a failed write leaves
+	// it behind
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return false, err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return false, err
+	}
+
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Check returns the unified diff turning the content of the file name into
+// data, or "" when the file already holds data. A missing file is diffed as
+// empty.
+func Check(name string, data []byte) (string, error) {
+	existing, err := os.ReadFile(name)
+	oldName := name
+	if errors.Is(err, fs.ErrNotExist) {
+		oldName = os.DevNull
+	} else if err != nil {
+		return "", err
+	}
+
+	return diff.Unified(oldName, name+" (generated)", existing, data), nil
+}
+
+// CheckRemoved returns the unified diff deleting the content of the file
+// name, for a file that is no longer generated.
+func CheckRemoved(name string) (string, error) {
+	existing, err := os.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+
+	return diff.Unified(name, os.DevNull, existing, nil), nil
+}
+
+// GeneratedFiles returns the sorted paths of the files of dir whose name
+// matches pattern and whose first line is header, the files written by a
+// previous run of the generator. A missing dir has none.
+func GeneratedFiles(dir, pattern, header string) ([]string, error) {
+	names, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return nil, err
+	}
+
+	var generated []string
+	for _, name := range names {
+		ok, err := firstLine(name, header)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			generated = append(generated, name)
+		}
+	}
+
+	return generated, nil
+}
+
+// firstLine reports whether the first line of the regular file name is
+// line.
+func firstLine(name, line string) (bool, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return false, err
+	}
+
+	first, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+
+	return strings.TrimSuffix(first, "\n") == line, nil
+}
+
+// PackageName returns the name of the Go package for files written to dir.
+// The package of the Go files already in dir is used when there are some,
+// otherwise the name is taken from the import path of dir within the module
+// of the nearest go.mod, or from the name of dir outside of a module.
+func PackageName(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if name, err := existingPackage(abs); err != nil || name != "" {
+		return name, err
+	}
+
+	importPath := filepath.Base(abs)
+	if root, module, err := findModule(abs); err != nil {
+		return "", err
+	} else if module != "" {
+		rel, err := filepath.Rel(root, abs)
+		if err != nil {
+			return "", err
+		}
+		importPath = path.Join(module, filepath.ToSlash(rel))
+	}
+
+	name := packageFromImportPath(importPath)
+	if !token.IsIdentifier(name) || name == "_" {
+		return "", fmt.Errorf("can't derive a package name for %s from %q, use -package", dir, importPath)
+	}
+
+	return name, nil
+}
+
+// existingPackage returns the package of the non test Go files in dir, or
+// "" when there are none.
+func existingPackage(dir string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", err
+	}
+
+	for _, match := range matches {
+		if strings.HasSuffix(match, "_test.go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(token.NewFileSet(), match, nil, parser.PackageClauseOnly)
+		if err != nil {
+			return "", err
+		}
+		return file.Name.Name, nil
+	}
+
+	return "", nil
+}
+
+// findModule returns the directory of the nearest go.mod at or above dir
+// and its module path, or empty strings when there is none.
+func findModule(dir string) (string, string, error) {
+	for {
+		f, err := os.Open(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			defer f.Close()
+			module, err := modulePath(f)
+			return dir, module, err
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", "", err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", nil
+		}
+		dir = parent
+	}
+}
+
+// modulePath returns the path of the module directive of a go.mod file.
+func modulePath(f *os.File) (string, error) {
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`+"`"), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", fmt.Errorf("%s: no module directive", f.Name())
+}
+
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// packageFromImportPath returns the conventional package name for an import
+// path: its last element, skipping a major version suffix, without a go-
+// prefix or -go suffix, lower case and stripped of the characters that
+// can't appear in an identifier, e.g. example.com/go-Config/v2 is config.
+func packageFromImportPath(importPath string) string {
+	elements := strings.Split(importPath, "/")
+	name := elements[len(elements)-1]
+	if majorVersion.MatchString(name) && len(elements) > 1 {
+		name = elements[len(elements)-2]
+	}
+
+	name = strings.ToLower(name)
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(name, "-go")
+
+	return strings.Map(func(r rune) rune {
+		if r == '_' || ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return -1
+	}, name)
+}
@@ -0,0 +1,209 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "types_gen.go")
+
+	written, err := WriteFile(name, []byte("package a\n"))
+	if err != nil || !written {
+		t.Fatalf("WriteFile() = %v, %v, want the new file written", written, err)
+	}
+
+	// Unchanged content keeps the file and its modification time
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(name, past, past); err != nil {
+		t.Fatalf("Failed to set the modification time: %v", err)
+	}
+	written, err = WriteFile(name, []byte("package a\n"))
+	if err != nil || written {
+		t.Fatalf("WriteFile() = %v, %v, want the unchanged file skipped", written, err)
+	}
+	if info, err := os.Stat(name); err != nil || !info.ModTime().Equal(past) {
+		t.Errorf("Expected the modification time to be kept, got %v, %v", info.ModTime(), err)
+	}
+
+	written, err = WriteFile(name, []byte("package b\n"))
+	if err != nil || !written {
+		t.Fatalf("WriteFile() = %v, %v, want the changed file written", written, err)
+	}
+	if data, _ := os.ReadFile(name); string(data) != "package b\n" {
+		t.Errorf("File content = %q, want %q", data, "package b\n")
+	}
+
+	// No temporary file is left behind
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected a single file in %s, got %v", dir, entries)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "types_gen.go")
+	if err := os.WriteFile(name, []byte("package a\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		file     string
+		data     string
+		expected string
+	}{
+		{
+			name:     "up to date",
+			file:     name,
+			data:     "package a\n",
+			expected: "",
+		},
+		{
+			name:     "changed",
+			file:     name,
+			data:     "package b\n",
+			expected: "--- " + name + "\n+++ " + name + " (generated)\n@@ -1,1 +1,1 @@\n-package a\n+package b\n",
+		},
+		{
+			name:     "missing",
+			file:     filepath.Join(dir, "missing.go"),
+			data:     "package a\n",
+			expected: "--- " + os.DevNull + "\n+++ " + filepath.Join(dir, "missing.go") + " (generated)\n@@ -0,0 +1,1 @@\n+package a\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Check(tt.file, []byte(tt.data))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Check() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCheckRemoved(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "server_gen.go")
+	if err := os.WriteFile(name, []byte("package a\n\ntype Server struct{}\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	result, err := CheckRemoved(name)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "--- " + name + "\n+++ " + os.DevNull + "\n@@ -1,3 +0,0 @@\n-package a\n-\n-type Server struct{}\n"
+	if result != expected {
+		t.Errorf("CheckRemoved() = %q, want %q", result, expected)
+	}
+}
+
+func TestGeneratedFiles(t *testing.T) {
+	const header = "// Code generated by yaml2go. DO NOT EDIT."
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"server_gen.go": header + "\n\npackage a\n",
+		"db_gen.go":     header + "\n",
+		"hand_gen.go":   "package a\n",
+		"other_gen.go":  "// Code generated by stringer. DO NOT EDIT.\n",
+		"types.go":      header + "\n",
+		"empty_gen.go":  "",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "dir_gen.go"), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	result, err := GeneratedFiles(dir, "*_gen.go", header)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{filepath.Join(dir, "db_gen.go"), filepath.Join(dir, "server_gen.go")}
+	if !slices.Equal(result, expected) {
+		t.Errorf("GeneratedFiles() = %v, want %v", result, expected)
+	}
+
+	result, err = GeneratedFiles(filepath.Join(dir, "missing"), "*_gen.go", header)
+	if err != nil || len(result) != 0 {
+		t.Errorf("GeneratedFiles() of a missing directory = %v, %v, want none", result, err)
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	files := map[string]string{
+		"mod/go.mod":                "module example.com/my-app // the app\n\ngo 1.24\n",
+		"mod/internal/go-config/v2": "",
+		"mod/existing/types.go":     "// Package settings holds the settings.\npackage settings\n",
+		"mod/existing/a_test.go":    "package settings_test\n",
+		"mod/tests/a_test.go":       "package tests_test\n",
+		"plain/Web-Server":          "",
+		"plain/2fa":                 "",
+	}
+
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if content == "" {
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				t.Fatalf("Failed to create directory: %v", err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		dir         string
+		expected    string
+		expectError bool
+	}{
+		{name: "module root", dir: "mod", expected: "myapp"},
+		{name: "major version suffix", dir: "mod/internal/go-config/v2", expected: "config"},
+		{name: "package of existing files", dir: "mod/existing", expected: "settings"},
+		{name: "test files ignored", dir: "mod/tests", expected: "tests"},
+		{name: "missing directory inside the module", dir: "mod/api/v1", expected: "api"},
+		{name: "outside of a module", dir: "plain/Web-Server", expected: "webserver"},
+		{name: "not an identifier", dir: "plain/2fa", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := PackageName(filepath.Join(root, tt.dir))
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error but got %q", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("PackageName(%q) = %v, want %v", tt.dir, result, tt.expected)
+			}
+		})
+	}
+}
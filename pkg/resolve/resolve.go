@@ -0,0 +1,136 @@
+package resolve
+
+import (
+	"github.com/goccy/go-yaml/ast"
+	"github.com/richerve/yaml2go/pkg/diag"
+	"github.com/richerve/yaml2go/pkg/naming"
+)
+
+// Document returns a copy of doc where anchors are replaced by their value,
+// aliases by the value of the anchor they refer to and merge keys by the
+// keys of the merged mappings. The input is left untouched.
+//
+// An alias resolves to the same node as its anchor, so every path holding
+// the anchored mapping can be recognized as the same struct.
+func Document(doc *ast.DocumentNode) (*ast.DocumentNode, diag.List) {
+	r := &resolver{
+		anchors: make(map[string]ast.Node),
+	}
+
+	resolved := *doc
+	resolved.Body = r.node(doc.Body)
+
+	return &resolved, r.diags
+}
+
+type resolver struct {
+	// anchors holds the resolved value of each anchor defined so far, a
+	// redefined anchor replaces the previous one for the aliases after it
+	anchors map[string]ast.Node
+	diags   diag.List
+}
+
+// node returns the resolved form of node. Diagnostics are only located by
+// position, the paths used elsewhere are named after the generated structs
+// which are not known yet.
+func (r *resolver) node(node ast.Node) ast.Node {
+	switch n := node.(type) {
+	case *ast.AnchorNode:
+		value := r.node(n.Value)
+		r.anchors[n.Name.String()] = value
+		return value
+
+	case *ast.AliasNode:
+		name := n.Value.String()
+		value, ok := r.anchors[name]
+		if !ok {
+			r.diags = append(r.diags, diag.New(n, nil, "alias *%s refers to an undefined anchor", name))
+			return ast.Null(n.Start)
+		}
+		return value
+
+	case *ast.TagNode:
+		resolved := *n
+		resolved.Value = r.node(n.Value)
+		return &resolved
+
+	case *ast.MappingNode:
+		return r.mapping(n)
+
+	case *ast.SequenceNode:
+		resolved := *n
+		resolved.Values = make([]ast.Node, len(n.Values))
+		for i, value := range n.Values {
+			resolved.Values[i] = r.node(value)
+		}
+		return &resolved
+
+	default:
+		return node
+	}
+}
+
+// mapping resolves the values of node and expands its merge keys in place.
+// Explicit keys take precedence over merged ones, and the mappings of a
+// merged list take precedence over the ones after them.
+func (r *resolver) mapping(node *ast.MappingNode) *ast.MappingNode {
+	// Keys are compared by their name, String includes the comments
+	explicit := make(map[string]bool)
+	for _, mappingValue := range node.Values {
+		if _, merge := mappingValue.Key.(*ast.MergeKeyNode); !merge {
+			explicit[naming.KeyName(mappingValue.Key)] = true
+		}
+	}
+
+	resolved := *node
+	resolved.Values = nil
+	merged := make(map[string]bool)
+
+	for _, mappingValue := range node.Values {
+		if _, merge := mappingValue.Key.(*ast.MergeKeyNode); !merge {
+			value := *mappingValue
+			value.Value = r.node(mappingValue.Value)
+			resolved.Values = append(resolved.Values, &value)
+			continue
+		}
+
+		for _, source := range r.mergeSources(mappingValue) {
+			for _, value := range source.Values {
+				key := naming.KeyName(value.Key)
+				if explicit[key] || merged[key] {
+					continue
+				}
+				merged[key] = true
+				resolved.Values = append(resolved.Values, value)
+			}
+		}
+	}
+
+	return &resolved
+}
+
+// mergeSources returns the resolved mappings merged by the merge key of
+// mappingValue, a single mapping or a list of them.
+func (r *resolver) mergeSources(mappingValue *ast.MappingValueNode) []*ast.MappingNode {
+	var values []ast.Node
+	switch value := r.node(mappingValue.Value).(type) {
+	case *ast.SequenceNode:
+		values = value.Values
+	default:
+		values = []ast.Node{value}
+	}
+
+	var sources []*ast.MappingNode
+	for _, value := range values {
+		switch v := value.(type) {
+		case *ast.MappingNode:
+			sources = append(sources, v)
+		case *ast.NullNode:
+			// An undefined alias, already reported
+		default:
+			r.diags = append(r.diags, diag.New(mappingValue.Key, nil, "merge key value must be a mapping or a list of mappings"))
+		}
+	}
+
+	return sources
+}
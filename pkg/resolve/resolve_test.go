@@ -0,0 +1,218 @@
+package resolve
+
+import (
+	"testing"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/richerve/yaml2go/pkg/naming"
+)
+
+func keys(node ast.Node) []string {
+	mapping, ok := node.(*ast.MappingNode)
+	if !ok {
+		return nil
+	}
+
+	var result []string
+	for _, value := range mapping.Values {
+		result = append(result, naming.KeyName(value.Key))
+	}
+	return result
+}
+
+func value(node ast.Node, key string) ast.Node {
+	mapping, ok := node.(*ast.MappingNode)
+	if !ok {
+		return nil
+	}
+
+	for _, v := range mapping.Values {
+		if v.Key.String() == key {
+			return v.Value
+		}
+	}
+	return nil
+}
+
+func TestDocument(t *testing.T) {
+	tests := []struct {
+		name      string
+		yamlInput string
+		key       string
+		expected  []string
+	}{
+		{
+			name: "anchor replaced by its value",
+			yamlInput: `
+base: &base
+  a: 1
+`,
+			key:      "base",
+			expected: []string{"a"},
+		},
+		{
+			name: "alias resolved to the anchored mapping",
+			yamlInput: `
+base: &base
+  a: 1
+copy: *base
+`,
+			key:      "copy",
+			expected: []string{"a"},
+		},
+		{
+			name: "merge key expanded",
+			yamlInput: `
+base: &base
+  a: 1
+  b: 2
+target:
+  <<: *base
+  c: 3
+`,
+			key:      "target",
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			name: "explicit keys override merged keys",
+			yamlInput: `
+base: &base
+  a: 1
+  b: 2
+target:
+  b: 3
+  <<: *base
+`,
+			key:      "target",
+			expected: []string{"b", "a"},
+		},
+		{
+			name: "explicit ? keys override merged keys",
+			yamlInput: `
+base: &base
+  a: 1
+  b: 2
+target:
+  ? b
+  : 3
+  <<: *base
+`,
+			key:      "target",
+			expected: []string{"b", "a"},
+		},
+		{
+			name: "list of merged mappings",
+			yamlInput: `
+one: &one
+  a: 1
+two: &two
+  a: 2
+  b: 2
+target:
+  <<: [*one, *two]
+`,
+			key:      "target",
+			expected: []string{"a", "b"},
+		},
+		{
+			name: "inline merged mapping",
+			yamlInput: `
+target:
+  <<: {a: 1}
+  b: 2
+`,
+			key:      "target",
+			expected: []string{"a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte(tt.yamlInput), 0)
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			doc, diags := Document(file.Docs[0])
+			if len(diags) > 0 {
+				t.Fatalf("Unexpected diagnostics: %v", diags)
+			}
+
+			result := keys(value(doc.Body, tt.key))
+			if len(result) != len(tt.expected) {
+				t.Fatalf("keys = %v, want %v", result, tt.expected)
+			}
+			for i := range tt.expected {
+				if result[i] != tt.expected[i] {
+					t.Errorf("keys = %v, want %v", result, tt.expected)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestDocument_SharesAnchoredNode(t *testing.T) {
+	file, err := parser.ParseBytes([]byte("base: &base {a: 1}\ncopy: *base\n"), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	doc, _ := Document(file.Docs[0])
+	if value(doc.Body, "base") != value(doc.Body, "copy") {
+		t.Errorf("Expected the alias to resolve to the anchored node")
+	}
+}
+
+func TestDocument_LeavesInputUntouched(t *testing.T) {
+	file, err := parser.ParseBytes([]byte("base: &base {a: 1}\ntarget:\n  <<: *base\n"), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	Document(file.Docs[0])
+
+	if _, ok := value(file.Docs[0].Body, "base").(*ast.AnchorNode); !ok {
+		t.Errorf("Expected the anchor to be kept in the input")
+	}
+	if result := keys(value(file.Docs[0].Body, "target")); len(result) != 1 || result[0] != "<<" {
+		t.Errorf("Expected the merge key to be kept in the input, got %v", result)
+	}
+}
+
+func TestDocument_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		yamlInput string
+		expected  string
+	}{
+		{
+			name:      "undefined alias",
+			yamlInput: "a: *missing\n",
+			expected:  "1:4: alias *missing refers to an undefined anchor",
+		},
+		{
+			name:      "merge of a scalar",
+			yamlInput: "s: &s 1\na:\n  <<: *s\n",
+			expected:  "3:3: merge key value must be a mapping or a list of mappings",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte(tt.yamlInput), 0)
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			_, diags := Document(file.Docs[0])
+			if len(diags) != 1 {
+				t.Fatalf("Expected 1 diagnostic, got %v", diags)
+			}
+			if diags[0].Error() != tt.expected {
+				t.Errorf("Diagnostic = %q, want %q", diags[0].Error(), tt.expected)
+			}
+		})
+	}
+}
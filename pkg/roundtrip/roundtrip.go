@@ -0,0 +1,359 @@
+package roundtrip
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/richerve/yaml2go/pkg/diag"
+	"github.com/richerve/yaml2go/pkg/generator"
+	"github.com/richerve/yaml2go/pkg/naming"
+	"github.com/richerve/yaml2go/pkg/resolve"
+)
+
+// Formats are the tag keys whose decoder a round trip can use: encoding/json
+// for json and github.com/goccy/go-yaml for yaml.
+var Formats = []string{"json", "yaml"}
+
+// Input is a YAML file decoded into the generated types.
+type Input struct {
+	// Name is the file name the problems are reported with
+	Name string
+	Data []byte
+	// File is Data parsed, to locate the problems
+	File *ast.File
+	// Roots is the root struct of each document, see Generator.Roots
+	Roots []generator.Root
+}
+
+//go:embed harness.go.tmpl
+var harnessSource string
+
+// harness is delimited by [[ and ]], the composite literals of the Go code
+// hold {{
+var harness = template.Must(template.New("harness").Delims("[[", "]]").Parse(harnessSource))
+
+const (
+	// module is the path of the temporary module holding the harness
+	module = "yaml2goroundtrip"
+	// yamlModule is the YAML library of the harness, the same as yaml2go
+	yamlModule = "github.com/goccy/go-yaml"
+)
+
+// Check proves that the generated types fit the YAML of inputs. A temporary
+// module holding source, a complete Go file, and a harness is run with the
+// go command: each document is decoded with the decoder of format into its
+// root struct, with unknown keys disallowed, then encoded back and compared
+// with the YAML. Documents that don't decode, keys lost and values changed
+// by the round trip are returned as a diag.List located in the YAML. The
+// error reports a harness that couldn't run.
+func Check(source []byte, format string, inputs []Input) (diag.List, error) {
+	if !slices.Contains(Formats, format) {
+		return nil, fmt.Errorf("no decoder for struct tag key %q, expected one of %s", format, strings.Join(Formats, ", "))
+	}
+
+	dir, err := os.MkdirTemp("", "yaml2go-roundtrip-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := writeModule(dir, source, inputs); err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("go", "run", ".", format, "inputs.json")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("running the round trip: %v\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	// The problems are listed by input, each in the order of the YAML
+	perInput := make([]diag.List, len(inputs))
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		var p problem
+		if err := json.Unmarshal(scanner.Bytes(), &p); err != nil {
+			return nil, fmt.Errorf("reading the round trip results: %w", err)
+		}
+		if p.Input < 0 || p.Input >= len(inputs) {
+			return nil, fmt.Errorf("reading the round trip results: no input %d", p.Input)
+		}
+		perInput[p.Input] = append(perInput[p.Input], p.diagnostic(inputs))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var diags diag.List
+	for _, l := range perInput {
+		l.Sort()
+		diags = append(diags, l...)
+	}
+
+	return diags, nil
+}
+
+// writeModule writes to dir the module running the harness: the generated
+// types in the types package, the harness and the inputs it decodes.
+func writeModule(dir string, source []byte, inputs []Input) error {
+	yamlDir, err := yamlModuleDir()
+	if err != nil {
+		return err
+	}
+
+	// The YAML library is used from the module cache, or the directory it
+	// is replaced with, the harness needs no network access once cached
+	goMod := fmt.Sprintf("module %s\n\ngo 1.21\n\nrequire %s v0.0.0\n\nreplace %s => %s\n", module, yamlModule, yamlModule, yamlDir)
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0o644); err != nil {
+		return err
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, "types"), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "types", "types.go"), source, 0o644); err != nil {
+		return err
+	}
+
+	type document struct {
+		Root string `json:"root"`
+		Key  string `json:"key"`
+	}
+	type input struct {
+		File      string     `json:"file"`
+		Documents []document `json:"documents"`
+	}
+
+	var roots []string
+	config := make([]input, 0, len(inputs))
+	for i, in := range inputs {
+		name := filepath.Join(dir, fmt.Sprintf("input%d.yaml", i))
+		if err := os.WriteFile(name, in.Data, 0o644); err != nil {
+			return err
+		}
+
+		documents := make([]document, 0, len(in.Roots))
+		for _, root := range in.Roots {
+			documents = append(documents, document{Root: root.Name, Key: root.Key})
+			if root.Name != "" && !slices.Contains(roots, root.Name) {
+				roots = append(roots, root.Name)
+			}
+		}
+		config = append(config, input{File: name, Documents: documents})
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "inputs.json"), data, 0o644); err != nil {
+		return err
+	}
+
+	var main bytes.Buffer
+	err = harness.Execute(&main, struct {
+		Module string
+		Roots  []string
+	}{module, roots})
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, "main.go"), main.Bytes(), 0o644)
+}
+
+// yamlModuleDir returns the directory holding the source of the YAML
+// library yaml2go is built with, downloading it to the module cache if
+// needed.
+func yamlModuleDir() (string, error) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", errors.New("no build information to find the version of " + yamlModule)
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path != yamlModule {
+			continue
+		}
+		if dep.Replace != nil {
+			if dep.Replace.Version == "" {
+				return dep.Replace.Path, nil
+			}
+			dep = dep.Replace
+		}
+
+		var stdout, stderr bytes.Buffer
+		cmd := exec.Command("go", "mod", "download", "-json", dep.Path+"@"+dep.Version)
+		cmd.Dir = os.TempDir()
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("downloading %s@%s: %v\n%s", dep.Path, dep.Version, err, strings.TrimSpace(stderr.String()))
+		}
+
+		var download struct{ Dir string }
+		if err := json.Unmarshal(stdout.Bytes(), &download); err != nil {
+			return "", err
+		}
+		return download.Dir, nil
+	}
+
+	return "", errors.New(yamlModule + " is not a dependency of this build")
+}
+
+// problem is a result of the harness, see harness.go.tmpl.
+type problem struct {
+	Input    int    `json:"input"`
+	Document int    `json:"document"`
+	Path     []any  `json:"path"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Message  string `json:"message"`
+}
+
+// diagnostic returns p located in inputs: at the position given by the
+// decoder, or else at the key or element reached by the path.
+func (p problem) diagnostic(inputs []Input) diag.Diagnostic {
+	d := diag.Diagnostic{
+		Line:    p.Line,
+		Column:  p.Column,
+		Message: "round trip: " + p.Message,
+	}
+	in := inputs[p.Input]
+	d.File = in.Name
+
+	var body ast.Node
+	if in.File != nil && p.Document < len(in.File.Docs) {
+		doc, _ := resolve.Document(in.File.Docs[p.Document])
+		body = doc.Body
+	}
+
+	// Decoders locate their errors by position only
+	segments := p.Path
+	if len(segments) == 0 && d.Line > 0 {
+		segments = pathAt(body, d.Line, d.Column)
+	}
+
+	// Paths start at the root struct, which holds the mapping of the single
+	// key of the document when it has one
+	path := []string{"Document"}
+	if p.Document < len(in.Roots) && in.Roots[p.Document].Name != "" {
+		root := in.Roots[p.Document]
+		path = []string{root.Name}
+		if root.Key != "" && len(segments) > 0 && segments[0] == root.Key {
+			segments = segments[1:]
+		}
+	}
+	for _, segment := range segments {
+		if index, ok := segment.(float64); ok {
+			path[len(path)-1] += fmt.Sprintf("[%d]", int(index))
+		} else {
+			path = append(path, fmt.Sprint(segment))
+		}
+	}
+	d.Path = strings.Join(path, ".")
+
+	if d.Line == 0 {
+		if node := locate(body, p.Path); node != nil {
+			if tk := node.GetToken(); tk != nil && tk.Position != nil {
+				d.Line, d.Column = tk.Position.Line, tk.Position.Column
+			}
+		}
+	}
+
+	return d
+}
+
+// locate returns the key or element reached by path from node, or the
+// closest one found when the path leaves the YAML.
+func locate(node ast.Node, path []any) ast.Node {
+	found := node
+	for _, segment := range path {
+		if tag, ok := node.(*ast.TagNode); ok {
+			node = tag.Value
+		}
+
+		switch n := node.(type) {
+		case *ast.MappingNode:
+			key := fmt.Sprint(segment)
+			i := slices.IndexFunc(n.Values, func(value *ast.MappingValueNode) bool {
+				return naming.KeyName(value.Key) == key
+			})
+			if i < 0 {
+				return found
+			}
+			found, node = n.Values[i].Key, n.Values[i].Value
+
+		case *ast.SequenceNode:
+			index, ok := segment.(float64)
+			if !ok || int(index) >= len(n.Values) {
+				return found
+			}
+			found, node = n.Values[int(index)], n.Values[int(index)]
+
+		default:
+			return found
+		}
+	}
+
+	return found
+}
+
+// pathAt returns the path from node to the key or value at line and column,
+// or nil when none is there.
+func pathAt(node ast.Node, line, column int) []any {
+	if tag, ok := node.(*ast.TagNode); ok {
+		node = tag.Value
+	}
+
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		for _, value := range n.Values {
+			key := naming.KeyName(value.Key)
+			if at(value.Key, line, column) || at(value.Value, line, column) {
+				return []any{key}
+			}
+			if path := pathAt(value.Value, line, column); path != nil {
+				return append([]any{key}, path...)
+			}
+		}
+
+	case *ast.SequenceNode:
+		for i, value := range n.Values {
+			if at(value, line, column) {
+				return []any{float64(i)}
+			}
+			if path := pathAt(value, line, column); path != nil {
+				return append([]any{float64(i)}, path...)
+			}
+		}
+	}
+
+	return nil
+}
+
+// at reports whether the token of node is at line and column.
+func at(node ast.Node, line, column int) bool {
+	if node == nil {
+		return false
+	}
+	tk := node.GetToken()
+
+	return tk != nil && tk.Position != nil && tk.Position.Line == line && tk.Position.Column == column
+}
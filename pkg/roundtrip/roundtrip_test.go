@@ -0,0 +1,185 @@
+package roundtrip
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/goccy/go-yaml/parser"
+	"github.com/richerve/yaml2go/pkg/generator"
+)
+
+func TestCheck(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the go command")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("no go command")
+	}
+
+	yaml := `config:
+  name: app
+  port: x
+  items:
+    - 1
+---
+name: b
+ratio: 1.5
+list:
+  - a: 1
+    b: 2
+---
+started: !!timestamp 2024-01-01T00:00:00Z
+---
+data: !!binary aGVsbG8=
+---
+name: c
+extra: 1
+`
+	source := "package types\n\n" +
+		"import \"time\"\n\n" +
+		"type Config struct {\n" +
+		"\tName string `json:\"name\" yaml:\"name\"`\n" +
+		"\tPort int `json:\"port\" yaml:\"port\"`\n" +
+		"\tItems []string `json:\"items\" yaml:\"items\"`\n" +
+		"}\n\n" +
+		"type Document2 struct {\n" +
+		"\tName string `json:\"name\" yaml:\"name\"`\n" +
+		"\tRatio float64 `json:\"ratio\" yaml:\"ratio\"`\n" +
+		"\tList []List `json:\"list\" yaml:\"list\"`\n" +
+		"}\n\n" +
+		"type List struct {\n" +
+		"\tA int `json:\"a\" yaml:\"a\"`\n" +
+		"}\n\n" +
+		"type Document3 struct {\n" +
+		"\tStarted time.Time `json:\"started\" yaml:\"started\"`\n" +
+		"}\n\n" +
+		"type Document4 struct {\n" +
+		"\tData []byte `json:\"data\" yaml:\"data\"`\n" +
+		"}\n\n" +
+		"type Document5 struct {\n" +
+		"\tName string `json:\"name\" yaml:\"name\"`\n" +
+		"}\n"
+
+	file, err := parser.ParseBytes([]byte(yaml), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+	inputs := []Input{{
+		Name:  "config.yaml",
+		Data:  []byte(yaml),
+		File:  file,
+		Roots: []generator.Root{{Name: "Config", Key: "config"}, {Name: "Document2"}, {Name: "Document3"}, {Name: "Document4"}, {Name: "Document5"}},
+	}}
+
+	tests := []struct {
+		format   string
+		expected []string
+	}{
+		{
+			format: "json",
+			expected: []string{
+				"config.yaml:5:7: Config.items[0]: round trip: cannot decode number into string",
+				"config.yaml:11:5: Document2.list[0].b: round trip: key lost",
+				"config.yaml:18:1: Document5.extra: round trip: key lost",
+			},
+		},
+		{
+			format: "yaml",
+			expected: []string{
+				"config.yaml:3:9: Config.port: round trip: cannot unmarshal string into Go struct field of type int",
+				`config.yaml:11:5: Document2.list[0].b: round trip: unknown field "b"`,
+				// The decoder panics on !!binary, the documents after it are
+				// checked all the same
+				"config.yaml:15:5: Document4: round trip: yaml panicked: runtime error: invalid memory address or nil pointer dereference",
+				`config.yaml:18:1: Document5.extra: round trip: unknown field "extra"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			diags, err := Check([]byte(source), tt.format, inputs)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, d := range diags {
+				got = append(got, d.Error())
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Check() = %q, expected %q", got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("Check()[%d] = %q, expected %q", i, got[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheck_UnknownFormat(t *testing.T) {
+	if _, err := Check([]byte("package types\n"), "toml", nil); err == nil {
+		t.Error("Expected an error for a format without decoder")
+	}
+}
+
+func TestProblem_diagnostic(t *testing.T) {
+	yaml := `server:
+  ports:
+    - 80
+    - 443
+  tls:
+    cert: a
+`
+	file, err := parser.ParseBytes([]byte(yaml), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+	inputs := []Input{{
+		Name:  "config.yaml",
+		File:  file,
+		Roots: []generator.Root{{Name: "Server", Key: "server"}},
+	}}
+
+	tests := []struct {
+		name     string
+		problem  problem
+		expected string
+	}{
+		{
+			name:     "located by path",
+			problem:  problem{Path: []any{"server", "tls", "cert"}, Message: "key lost"},
+			expected: "config.yaml:6:5: Server.tls.cert: round trip: key lost",
+		},
+		{
+			name:     "located by index",
+			problem:  problem{Path: []any{"server", "ports", float64(1)}, Message: "value changed"},
+			expected: "config.yaml:4:7: Server.ports[1]: round trip: value changed",
+		},
+		{
+			name:     "located by position",
+			problem:  problem{Line: 3, Column: 7, Message: "cannot unmarshal"},
+			expected: "config.yaml:3:7: Server.ports[0]: round trip: cannot unmarshal",
+		},
+		{
+			name:     "path leaving the YAML",
+			problem:  problem{Path: []any{"server", "tls", "key"}, Message: "key lost"},
+			expected: "config.yaml:5:3: Server.tls.key: round trip: key lost",
+		},
+		{
+			name:     "document without root struct",
+			problem:  problem{Document: 1, Message: "failed"},
+			expected: "config.yaml: Document: round trip: failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.problem.diagnostic(inputs).Error(); got != tt.expected {
+				t.Errorf("diagnostic() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+// Package schema describes the structure inferred from the YAML documents,
+// independently of the code generated from it: the objects built from the
+// mappings, the fields of their keys and the type of each value.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Kind classifies the values of a Type.
+type Kind string
+
+const (
+	// KindAny holds values of different kinds, or of a kind that is unknown.
+	KindAny Kind = "any"
+	// KindNull is the kind of a value that was only ever null, unknown until a
+	// concrete value is merged in.
+	KindNull      Kind = "null"
+	KindString    Kind = "string"
+	KindInteger   Kind = "integer"
+	KindFloat     Kind = "float"
+	KindBoolean   Kind = "boolean"
+	KindTimestamp Kind = "timestamp"
+	// KindBinary is the decoded data of a !!binary value.
+	KindBinary Kind = "binary"
+	// KindSet is a !!set, a mapping whose keys are the elements.
+	KindSet Kind = "set"
+	// KindArray is a sequence, of elements of the Items type.
+	KindArray Kind = "array"
+	// KindMap is a mapping without keys, whose keys are unknown.
+	KindMap Kind = "map"
+	// KindObject is a mapping with keys, described by the named Object.
+	KindObject Kind = "object"
+)
+
+// Type is the type of a value.
+type Type struct {
+	Kind Kind `json:"kind"`
+	// Object names the object of a KindObject value.
+	Object string `json:"object,omitempty"`
+	// Items is the type of the elements of a KindArray, KindAny when it has
+	// none.
+	Items *Type `json:"items,omitempty"`
+	// Nullable is set when the value is null in some of the samples merged
+	// into the type.
+	Nullable bool `json:"nullable,omitempty"`
+}
+
+// ArrayOf returns the type of an array of items.
+func ArrayOf(items Type) Type {
+	return Type{Kind: KindArray, Items: &items}
+}
+
+// ObjectOf returns the type of a value holding the keys of the named object.
+func ObjectOf(name string) Type {
+	return Type{Kind: KindObject, Object: name}
+}
+
+// String returns a short notation of t: the kind of scalars, the object name
+// of objects and []items for arrays, prefixed with ? when nullable, e.g.
+// []integer or ?Server.
+func (t Type) String() string {
+	s := string(t.Kind)
+	switch t.Kind {
+	case KindObject:
+		s = t.Object
+	case KindArray:
+		s = "[]" + t.Element().String()
+	}
+	if t.Nullable {
+		s = "?" + s
+	}
+
+	return s
+}
+
+// Element returns the type of the elements of an array, KindAny for any
+// other type.
+func (t Type) Element() Type {
+	if t.Items == nil {
+		return Type{Kind: KindAny}
+	}
+
+	return *t.Items
+}
+
+// Equal reports whether t and other describe the same values.
+func (t Type) Equal(other Type) bool {
+	if t.Kind != other.Kind || t.Object != other.Object || t.Nullable != other.Nullable {
+		return false
+	}
+	if t.Kind == KindArray {
+		return t.Element().Equal(other.Element())
+	}
+
+	return true
+}
+
+// Unify returns a type able to hold values of both a and b: integers and
+// floats widen to floats, arrays unify their elements, an array without
+// elements and a null value take the type of the other and anything else
+// that differs is KindAny. The result is nullable when either side is.
+func Unify(a, b Type) Type {
+	nullable := a.Nullable || b.Nullable || a.Kind == KindNull || b.Kind == KindNull
+	a.Nullable, b.Nullable = false, false
+
+	var t Type
+	switch {
+	case a.Equal(b):
+		t = a
+	case a.Kind == KindNull:
+		t = b
+	case b.Kind == KindNull:
+		t = a
+	case a.Kind == KindArray && b.Kind == KindArray:
+		switch {
+		case a.Element().Kind == KindAny:
+			t = b
+		case b.Element().Kind == KindAny:
+			t = a
+		default:
+			t = ArrayOf(Unify(a.Element(), b.Element()))
+		}
+	case (a.Kind == KindInteger && b.Kind == KindFloat) || (a.Kind == KindFloat && b.Kind == KindInteger):
+		t = Type{Kind: KindFloat}
+	default:
+		t = Type{Kind: KindAny}
+	}
+
+	// Null is nullable and any holds null already
+	t.Nullable = nullable && t.Kind != KindAny && t.Kind != KindNull
+
+	return t
+}
+
+// Source locates the YAML that produced an object or a field.
+type Source struct {
+	File   string `json:"file,omitempty"`
+	Path   string `json:"path"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+// Field is a key of an object.
+type Field struct {
+	// Key is the YAML key.
+	Key string `json:"key"`
+	// Name is the identifier of the field when the one derived from the key
+	// is taken by another key of the object, e.g. FooBar2 for foo-bar after
+	// foo_bar.
+	Name string `json:"name,omitempty"`
+	Type Type   `json:"type"`
+	// Optional is set when the value may be absent: it was empty or null in
+	// the YAML, or missing from some of the mappings merged into the object.
+	Optional bool `json:"optional,omitempty"`
+	// Empty is set when a value of the key is empty, such as "", 0 or [], in
+	// any of the mappings merged into the object.
+	Empty bool `json:"empty,omitempty"`
+	// Comment is the text of the YAML comments of the key.
+	Comment string `json:"comment,omitempty"`
+	// Source is the first key the field was inferred from.
+	Source Source `json:"source"`
+}
+
+// Object is the union of the mappings found for a path of keys.
+type Object struct {
+	Name   string  `json:"name"`
+	Fields []Field `json:"fields"`
+	// Comment is the text of the YAML comments of the key holding the
+	// mapping.
+	Comment string `json:"comment,omitempty"`
+	// Source is the first mapping the object was inferred from.
+	Source Source `json:"source"`
+}
+
+// Schema is the structure of a set of YAML documents.
+type Schema struct {
+	// Roots names the objects of the documents, in document order and
+	// without repetition. Documents that are not mappings have none.
+	Roots []string `json:"roots"`
+	// Objects are the root objects followed by the others sorted by name.
+	Objects []Object `json:"objects"`
+	// Keys maps the roots inferred from the mapping under the single key of
+	// their documents to that key, e.g. Server for server: {port: 80}.
+	Keys map[string]string `json:"keys,omitempty"`
+}
+
+// Object returns the object of s named name.
+func (s Schema) Object(name string) (Object, bool) {
+	for _, o := range s.Objects {
+		if o.Name == name {
+			return o, true
+		}
+	}
+
+	return Object{}, false
+}
+
+// JSON returns s encoded as indented JSON, to inspect the inferred
+// structure.
+func (s Schema) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding schema: %w", err)
+	}
+
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,130 @@
+package schema
+
+import "testing"
+
+func TestType_String(t *testing.T) {
+	tests := []struct {
+		typ      Type
+		expected string
+	}{
+		{typ: Type{Kind: KindString}, expected: "string"},
+		{typ: Type{Kind: KindNull}, expected: "null"},
+		{typ: ObjectOf("Server"), expected: "Server"},
+		{typ: Type{Kind: KindObject, Object: "Server", Nullable: true}, expected: "?Server"},
+		{typ: ArrayOf(ArrayOf(Type{Kind: KindInteger})), expected: "[][]integer"},
+		{typ: Type{Kind: KindArray}, expected: "[]any"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if result := tt.typ.String(); result != tt.expected {
+				t.Errorf("String() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUnify(t *testing.T) {
+	str := Type{Kind: KindString}
+	integer := Type{Kind: KindInteger}
+	float := Type{Kind: KindFloat}
+	null := Type{Kind: KindNull}
+	server := ObjectOf("Server")
+
+	tests := []struct {
+		name     string
+		a        Type
+		b        Type
+		expected string
+	}{
+		{name: "same type", a: str, b: str, expected: "string"},
+		{name: "integer and float", a: integer, b: float, expected: "float"},
+		{name: "float and integer", a: float, b: integer, expected: "float"},
+		{name: "mixed scalars", a: integer, b: str, expected: "any"},
+		{name: "arrays", a: ArrayOf(integer), b: ArrayOf(float), expected: "[]float"},
+		{name: "empty array", a: ArrayOf(Type{Kind: KindAny}), b: ArrayOf(server), expected: "[]Server"},
+		{name: "array and scalar", a: ArrayOf(integer), b: integer, expected: "any"},
+		{name: "object and scalar", a: server, b: str, expected: "any"},
+		{name: "different objects", a: server, b: ObjectOf("Client"), expected: "any"},
+		{name: "null and object", a: null, b: server, expected: "?Server"},
+		{name: "scalar and null", a: integer, b: null, expected: "?integer"},
+		{name: "null and null", a: null, b: null, expected: "null"},
+		{name: "null and any", a: null, b: Type{Kind: KindAny}, expected: "any"},
+		{name: "nullable side", a: Type{Kind: KindInteger, Nullable: true}, b: float, expected: "?float"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := Unify(tt.a, tt.b); result.String() != tt.expected {
+				t.Errorf("Unify(%v, %v) = %v, want %v", tt.a, tt.b, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSchema_JSON(t *testing.T) {
+	s := Schema{
+		Roots: []string{"Document"},
+		Objects: []Object{{
+			Name: "Document",
+			Fields: []Field{{
+				Key:      "ports",
+				Type:     ArrayOf(Type{Kind: KindInteger}),
+				Optional: true,
+				Source:   Source{File: "app.yaml", Path: "Document.ports", Line: 1, Column: 1},
+			}},
+			Source: Source{File: "app.yaml", Path: "Document", Line: 1, Column: 6},
+		}},
+	}
+
+	expected := `{
+  "roots": [
+    "Document"
+  ],
+  "objects": [
+    {
+      "name": "Document",
+      "fields": [
+        {
+          "key": "ports",
+          "type": {
+            "kind": "array",
+            "items": {
+              "kind": "integer"
+            }
+          },
+          "optional": true,
+          "source": {
+            "file": "app.yaml",
+            "path": "Document.ports",
+            "line": 1,
+            "column": 1
+          }
+        }
+      ],
+      "source": {
+        "file": "app.yaml",
+        "path": "Document",
+        "line": 1,
+        "column": 6
+      }
+    }
+  ]
+}
+`
+
+	data, err := s.JSON()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(data) != expected {
+		t.Errorf("JSON() mismatch:\nExpected:\n%s\nGot:\n%s", expected, data)
+	}
+
+	if _, ok := s.Object("Document"); !ok {
+		t.Errorf("Object(%q) not found", "Document")
+	}
+	if _, ok := s.Object("Server"); ok {
+		t.Errorf("Object(%q) found", "Server")
+	}
+}
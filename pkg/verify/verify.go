@@ -0,0 +1,171 @@
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// Error is a problem found in generated Go code, located in the source by
+// line and column and by the declaration holding it.
+type Error struct {
+	Line   int
+	Column int
+	// Struct is the type or variable declaration holding the problem and
+	// Field the struct field within it, empty when the problem is elsewhere.
+	Struct  string
+	Field   string
+	Message string
+}
+
+func (e Error) Error() string {
+	switch {
+	case e.Field != "":
+		return fmt.Sprintf("%d:%d: %s.%s: %s", e.Line, e.Column, e.Struct, e.Field, e.Message)
+	case e.Struct != "":
+		return fmt.Sprintf("%d:%d: %s: %s", e.Line, e.Column, e.Struct, e.Message)
+	default:
+		return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+	}
+}
+
+// Source parses and type-checks src, a complete Go file, and returns every
+// problem found, or nil when it compiles. Imported packages are type-checked
+// from the sources of GOROOT, no network access or build is needed.
+func Source(src []byte) []Error {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "generated.go", src, parser.AllErrors)
+
+	var positioned []positionedError
+	if err != nil {
+		var list scanner.ErrorList
+		if !errors.As(err, &list) {
+			return []Error{{Message: err.Error()}}
+		}
+		for _, e := range list {
+			positioned = append(positioned, positionedError{pos: e.Pos, message: e.Msg})
+		}
+	} else {
+		conf := types.Config{
+			Importer: importer.ForCompiler(fset, "source", nil),
+			Error: func(err error) {
+				// Continuation lines, such as the other declaration of a
+				// redeclared field, add nothing to the error before them
+				if e, ok := err.(types.Error); ok && !strings.HasPrefix(e.Msg, "\t") {
+					positioned = append(positioned, positionedError{pos: fset.Position(e.Pos), message: e.Msg})
+				}
+			},
+		}
+		// The errors are collected by conf.Error
+		_, _ = conf.Check(file.Name.Name, fset, []*ast.File{file}, nil)
+	}
+
+	lines := declarations(src)
+	var result []Error
+	for _, e := range positioned {
+		d := lines[e.pos.Line]
+		result = append(result, Error{
+			Line:    e.pos.Line,
+			Column:  e.pos.Column,
+			Struct:  d.name,
+			Field:   d.field,
+			Message: e.message,
+		})
+	}
+
+	return result
+}
+
+type positionedError struct {
+	pos     token.Position
+	message string
+}
+
+// declaration is the type or variable declaration and the struct field
+// within it a line of the source belongs to.
+type declaration struct {
+	name  string
+	field string
+}
+
+// declarations returns the declaration of each line of src holding one.
+// The lines are found from the tokens of src rather than its syntax tree,
+// the problems of source that doesn't parse are located the same way as
+// the ones of source that doesn't type-check.
+func declarations(src []byte) map[int]declaration {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	// Errors are reported by the parser, the scanner only goes past them
+	s.Init(file, src, nil, 0)
+
+	lines := make(map[int]declaration)
+	var (
+		current declaration
+		// keyword is the token starting the current declaration, depth the
+		// nesting of braces and parentheses within it
+		keyword    token.Token
+		depth      int
+		fieldStart bool
+	)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			if current.name != "" {
+				lines[file.Line(pos)] = current
+			}
+			break
+		}
+		line := file.Line(pos)
+
+		switch {
+		case depth == 0 && (tok == token.TYPE || tok == token.VAR || tok == token.CONST):
+			keyword, current, fieldStart = tok, declaration{}, false
+		case depth == 0 && tok == token.IDENT && keyword != token.ILLEGAL && current.name == "":
+			current.name = lit
+		case depth == 0 && tok == token.SEMICOLON:
+			keyword, current = token.ILLEGAL, declaration{}
+			continue
+		case tok == token.LBRACE || tok == token.LPAREN || tok == token.LBRACK:
+			depth++
+			fieldStart = keyword == token.TYPE && depth == 1
+		case tok == token.RBRACE || tok == token.RPAREN || tok == token.RBRACK:
+			depth = max(depth-1, 0)
+			if depth == 0 {
+				current.field, fieldStart = "", false
+			}
+		case keyword == token.TYPE && depth == 1 && tok == token.SEMICOLON:
+			fieldStart = true
+			continue
+		case fieldStart:
+			// The field name is the first word of its line, which may not
+			// be a single token when it isn't a valid identifier
+			current.field = firstWord(src[file.Offset(pos):])
+			fieldStart = false
+		}
+
+		if current.name != "" {
+			if _, ok := lines[line]; !ok {
+				lines[line] = current
+			}
+		}
+	}
+
+	return lines
+}
+
+// firstWord returns the text of src before the first space.
+func firstWord(src []byte) string {
+	word, _, _ := strings.Cut(string(src), "\n")
+	if i := strings.IndexAny(word, " \t"); i >= 0 {
+		word = word[:i]
+	}
+
+	return word
+}
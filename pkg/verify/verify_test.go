@@ -0,0 +1,152 @@
+package verify
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected []string
+	}{
+		{
+			name: "valid",
+			source: `package config
+
+import "time"
+
+type Config struct {
+	Name    *string
+	Started time.Time
+	Server  Server
+}
+
+type Server struct {
+	Ports []int
+}
+`,
+			expected: nil,
+		},
+		{
+			name: "undefined type",
+			source: `package config
+
+type Config struct {
+	Nested NestedStruct
+}
+`,
+			expected: []string{"4:9: Config.Nested: undefined: NestedStruct"},
+		},
+		{
+			name: "duplicate field",
+			source: `package config
+
+type Config struct {
+	Name string
+	Name int
+}
+`,
+			expected: []string{"5:2: Config.Name: Name redeclared"},
+		},
+		{
+			name: "duplicate type",
+			source: `package config
+
+type Config struct{}
+
+type Config struct{}
+`,
+			expected: []string{"5:6: Config: Config redeclared in this block"},
+		},
+		{
+			name: "value of the wrong type",
+			source: `package config
+
+type Config struct {
+	Port int
+}
+
+var ConfigValue = Config{
+	Port: "80",
+}
+`,
+			expected: []string{`8:8: ConfigValue: cannot use "80" (untyped string constant) as int value in struct literal`},
+		},
+		{
+			name: "invalid field name",
+			source: `package config
+
+type Config struct {
+	2fa string
+}
+`,
+			expected: []string{
+				"4:2: Config.2fa: expected '}', found 2",
+				"4:3: Config.2fa: expected ';', found fa",
+			},
+		},
+		{
+			name: "syntax error in a later field",
+			source: `package config
+
+type Config struct {
+	Name string
+	Port int ` + "`json:\"port\"` `yaml:\"port\"`" + `
+}
+
+type Server struct {
+	Host string
+}
+`,
+			expected: []string{
+				"5:25: Config.Port: expected ';', found `yaml:\"port\"`",
+				"8:1: Server: expected '}', found 'type'",
+				"8:6: Server: expected ';', found Server",
+			},
+		},
+		{
+			name: "syntax error in a value",
+			source: `package config
+
+type Config struct {
+	Port int
+}
+
+var ConfigValue = Config{
+	Port: 80 +,
+}
+`,
+			expected: []string{
+				"8:12: ConfigValue: expected operand, found ','",
+				"9:3: ConfigValue: expected ';', found 'EOF'",
+				"9:3: ConfigValue: expected '}', found 'EOF'",
+				"9:3: ConfigValue: missing ',' in composite literal",
+			},
+		},
+		{
+			name: "syntax error outside of the fields",
+			source: `package config
+
+type Config struct {
+	Port int
+} extra
+`,
+			expected: []string{"5:3: Config: expected ';', found extra"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result []string
+			for _, e := range Source([]byte(tt.source)) {
+				result = append(result, e.Error())
+			}
+
+			if strings.Join(result, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("Source() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
@@ -1,27 +1,59 @@
 package visitor
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/goccy/go-yaml/ast"
 	"github.com/richerve/yaml2go/pkg/codegen"
+	"github.com/richerve/yaml2go/pkg/diag"
+	"github.com/richerve/yaml2go/pkg/ident"
 	"github.com/richerve/yaml2go/pkg/inference"
+	"github.com/richerve/yaml2go/pkg/naming"
+	"github.com/richerve/yaml2go/pkg/schema"
 )
 
+// Options configures the objects inferred from the visited nodes.
+type Options struct {
+	// File names the YAML file holding the visited nodes in the Source of
+	// the objects and fields.
+	File string
+	// Samples treats the mappings merged into an object as samples of one
+	// schema: a field is optional only when its key is missing from some of
+	// them or null, keys present with an empty value such as 0 or "" are
+	// required.
+	Samples bool
+}
+
+// ASTVisitor infers the objects of the mappings it visits, merging the ones
+// found for the same path.
 type ASTVisitor struct {
-	structs     map[string]codegen.StructDef
-	path        []string
-	tagPrefix   string
-	useOmitZero bool
+	structs map[string]schema.Object
+	names   *naming.Registry
+	path    []string
+	opts    Options
+	// diags is shared by the visitors created for nested paths
+	diags *diag.List
+	// comment documents the object built for path, taken from the key
+	// holding it
+	comment string
 }
 
-func NewASTVisitor(structs map[string]codegen.StructDef, path []string, tagPrefix string, useOmitZero bool) *ASTVisitor {
+func NewASTVisitor(structs map[string]schema.Object, names *naming.Registry, path []string, opts Options) *ASTVisitor {
 	return &ASTVisitor{
-		structs:     structs,
-		path:        path,
-		tagPrefix:   tagPrefix,
-		useOmitZero: useOmitZero,
+		structs: structs,
+		names:   names,
+		path:    path,
+		opts:    opts,
+		diags:   &diag.List{},
 	}
 }
 
+// Diagnostics returns the problems found while visiting.
+func (v *ASTVisitor) Diagnostics() diag.List {
+	return *v.diags
+}
+
 func (v *ASTVisitor) Visit(node ast.Node) ast.Visitor {
 	switch n := node.(type) {
 	case *ast.DocumentNode:
@@ -35,12 +67,26 @@ func (v *ASTVisitor) Visit(node ast.Node) ast.Visitor {
 		return v.visitMappingValueNode(n)
 
 	case *ast.SequenceNode:
-		// Sequences are handled in determineType, don't traverse further
+		return v.visitSequenceNode(n)
+
+	case *ast.TagNode:
+		if _, typed := inference.TagType(n); typed {
+			// The tag gives the type, e.g. the mapping holding a !!set
+			return nil
+		}
+		// The tagged value is walked with the same path
+		return v
+
+	case *ast.StringNode, *ast.LiteralNode, *ast.IntegerNode, *ast.FloatNode, *ast.InfinityNode, *ast.NanNode, *ast.BoolNode, *ast.NullNode:
+		// Scalars are typed by their parent, nothing to traverse
+		return nil
+
+	case *ast.CommentGroupNode, *ast.CommentNode, nil:
 		return nil
 
 	default:
-		// For other node types, continue traversal
-		return v
+		*v.diags = append(*v.diags, diag.New(node, v.path, "unsupported YAML node %s", node.Type()))
+		return nil
 	}
 }
 
@@ -52,60 +98,102 @@ func (v *ASTVisitor) visitMappingNode(node *ast.MappingNode) ast.Visitor {
 
 	// Non-empty mapping - create struct
 	structName := v.getCurrentStructName()
-	var fields []codegen.FieldDef
+	if !ident.IsExported(structName) {
+		*v.diags = append(*v.diags, diag.New(node, v.path, "struct name %q is not a valid Go identifier", structName))
+	}
+
+	var fields []schema.Field
+
+	// Keys already holding each Go field name, including the fields merged
+	// from previous occurrences of the struct
+	goNames := make(map[string]string)
+	keyNames := make(map[string]string)
+	for _, field := range v.structs[structName].Fields {
+		goNames[fieldName(field)] = field.Key
+		keyNames[field.Key] = fieldName(field)
+	}
 
 	for _, mappingValue := range node.Values {
 		keyNode := mappingValue.Key
-		keyValue := keyNode.String()
-		fieldName := keyValue
-		fieldType := inference.DetermineType(mappingValue.Value, keyValue, v.structs, v.path)
-
-		flags := []string{}
-		// Check if value is empty and add omitempty/omitzero tag
-		if inference.IsEmptyValue(mappingValue.Value) {
-			if v.useOmitZero {
-				flags = append(flags, "omitzero")
-			} else {
-				flags = append(flags, "omitempty")
+		keyValue := naming.KeyName(keyNode)
+
+		goName, seen := keyNames[keyValue]
+		renamed := ""
+		if !seen {
+			goName = ident.Exported(keyValue)
+			if other, exists := goNames[goName]; exists {
+				// Keys such as foo_bar and fooBar produce the same name, the
+				// first key keeps it and the others get a numeric suffix
+				renamed = uniqueName(goName, goNames)
+				fieldPath := append(v.path[:len(v.path):len(v.path)], keyValue)
+				*v.diags = append(*v.diags, diag.Warn(keyNode, fieldPath, "key %q produces field name %s already used by key %q in struct %s, renamed to %s", keyValue, goName, other, structName, renamed))
+				goName = renamed
 			}
 		}
+		goNames[goName] = keyValue
+		keyNames[keyValue] = goName
 
-		fd := codegen.FieldDef{
-			Name: fieldName,
-			Type: fieldType,
-			Tag: &codegen.FieldTag{
-				Prefix: v.tagPrefix,
-				Value:  fieldName,
-				Flags:  flags,
-			},
-		}
+		fields = append(fields, schema.Field{
+			Key:      keyValue,
+			Name:     renamed,
+			Type:     inference.DetermineType(mappingValue.Value, keyValue, v.names, v.path),
+			Optional: v.optional(mappingValue.Value),
+			Empty:    inference.IsEmptyValue(mappingValue.Value),
+			Comment:  KeyComment(mappingValue),
+			Source:   v.source(keyNode, append(v.path[:len(v.path):len(v.path)], keyValue)),
+		})
+	}
 
-		fields = append(fields, fd)
+	// Every path resolves to its own name, a struct that already exists was
+	// produced by another element of a sequence, another document or an
+	// identical shape and gets the union of the fields
+	comment := v.comment
+	source := v.source(node, v.path)
+	if existing, ok := v.structs[structName]; ok {
+		fields = mergeFields(existing.Fields, fields)
+		if existing.Comment != "" {
+			comment = existing.Comment
+		}
+		source = existing.Source
 	}
 
-	// Store struct definition
-	v.structs[structName] = codegen.StructDef{
-		Name:   structName,
-		Fields: fields,
+	v.structs[structName] = schema.Object{
+		Name:    structName,
+		Fields:  fields,
+		Comment: comment,
+		Source:  source,
 	}
 
 	// Continue traversal to handle nested structures
 	return v
 }
 
+// source locates node, reached by path, in the visited file.
+func (v *ASTVisitor) source(node ast.Node, path []string) schema.Source {
+	d := diag.New(node, path, "")
+	return schema.Source{
+		File:   v.opts.File,
+		Path:   d.Path,
+		Line:   d.Line,
+		Column: d.Column,
+	}
+}
+
 func (v *ASTVisitor) visitMappingValueNode(node *ast.MappingValueNode) ast.Visitor {
 	keyNode := node.Key
-	keyValue := keyNode.String()
+	keyValue := naming.KeyName(keyNode)
 	// Create new visitor with updated path for nested structures
 	newPath := make([]string, len(v.path))
 	copy(newPath, v.path)
 	newPath = append(newPath, keyValue)
 
 	newVisitor := &ASTVisitor{
-		structs:     v.structs,
-		path:        newPath,
-		tagPrefix:   v.tagPrefix,
-		useOmitZero: v.useOmitZero,
+		structs: v.structs,
+		names:   v.names,
+		path:    newPath,
+		opts:    v.opts,
+		diags:   v.diags,
+		comment: KeyComment(node),
 	}
 
 	// Walk the value with the updated path context
@@ -115,12 +203,154 @@ func (v *ASTVisitor) visitMappingValueNode(node *ast.MappingValueNode) ast.Visit
 	return nil
 }
 
-func (v *ASTVisitor) getCurrentStructName() string {
-	if len(v.path) == 0 {
-		return "Document"
+func (v *ASTVisitor) visitSequenceNode(node *ast.SequenceNode) ast.Visitor {
+	for _, value := range node.Values {
+		if tag, ok := value.(*ast.TagNode); ok {
+			if _, typed := inference.TagType(tag); !typed {
+				value = tag.Value
+			}
+		}
+
+		newPath := make([]string, len(v.path))
+		copy(newPath, v.path)
+
+		switch value.(type) {
+		case *ast.MappingNode:
+			// Mapping elements are named after the singular form of the key
+			lastKey := "Document"
+			if len(v.path) > 0 {
+				lastKey = v.path[len(v.path)-1]
+			}
+			newPath = append(newPath, codegen.ElementName(lastKey))
+		case *ast.SequenceNode:
+			// Nested sequences keep the path of the outer sequence
+		default:
+			// Scalar elements don't produce structs
+			continue
+		}
+
+		elementVisitor := &ASTVisitor{
+			structs: v.structs,
+			names:   v.names,
+			path:    newPath,
+			opts:    v.opts,
+			diags:   v.diags,
+		}
+		ast.Walk(elementVisitor, value)
+	}
+
+	// Elements have been walked with their own visitors
+	return nil
+}
+
+// mergeFields appends the fields of next that are not already present in
+// existing, preserving the order in which fields were first seen. Fields
+// missing from either side become optional, fields empty on either side are
+// empty and the types are unified, a field null on one side takes the
+// nullable type found on the other.
+func mergeFields(existing, next []schema.Field) []schema.Field {
+	merged := make([]schema.Field, len(existing))
+	copy(merged, existing)
+
+	for i, field := range merged {
+		found := false
+		for _, n := range next {
+			if n.Key == field.Key {
+				found = true
+				merged[i].Optional = field.Optional || n.Optional
+				merged[i].Empty = field.Empty || n.Empty
+				if field.Comment == "" {
+					merged[i].Comment = n.Comment
+				}
+				merged[i].Type = schema.Unify(field.Type, n.Type)
+				break
+			}
+		}
+		if !found {
+			merged[i].Optional = true
+		}
+	}
+
+	for _, field := range next {
+		found := false
+		for _, e := range existing {
+			if e.Key == field.Key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			field.Optional = true
+			merged = append(merged, field)
+		}
 	}
 
-	// Use the last element in path as struct name
-	lastKey := v.path[len(v.path)-1]
-	return codegen.Capitalize(lastKey)
+	return merged
+}
+
+// optional reports whether the field holding node may be absent, see
+// Options.Samples.
+func (v *ASTVisitor) optional(node ast.Node) bool {
+	if v.opts.Samples {
+		_, null := node.(*ast.NullNode)
+		return null
+	}
+
+	return inference.IsOptionalValue(node)
+}
+
+// fieldName returns the Go name of field, see schema.Field.Name.
+func fieldName(field schema.Field) string {
+	if field.Name != "" {
+		return field.Name
+	}
+
+	return ident.Exported(field.Key)
+}
+
+// uniqueName returns name followed by the first number from 2 that is not a
+// key of taken.
+func uniqueName(name string, taken map[string]string) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s%d", name, i)
+		if _, exists := taken[candidate]; !exists {
+			return candidate
+		}
+	}
+}
+
+// KeyComment returns the text of the comments documenting a key: the comment
+// lines above it followed by the comment at the end of its line, without the
+// leading "# ".
+func KeyComment(node *ast.MappingValueNode) string {
+	var lines []string
+	lines = append(lines, commentLines(node.GetComment())...)
+
+	// Line comments are attached to scalar values, or to the key when the
+	// value starts on the next line
+	switch node.Value.(type) {
+	case *ast.MappingNode, *ast.SequenceNode, *ast.TagNode:
+		lines = append(lines, commentLines(node.Key.GetComment())...)
+	default:
+		lines = append(lines, commentLines(node.Value.GetComment())...)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func commentLines(group *ast.CommentGroupNode) []string {
+	if group == nil {
+		return nil
+	}
+
+	var lines []string
+	for _, comment := range group.Comments {
+		line := strings.TrimPrefix(comment.Token.Value, " ")
+		lines = append(lines, strings.TrimRight(line, " \t"))
+	}
+	return lines
+}
+
+func (v *ASTVisitor) getCurrentStructName() string {
+	return v.names.StructName(v.path)
 }
@@ -1,11 +1,12 @@
 package visitor
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/goccy/go-yaml/ast"
 	"github.com/goccy/go-yaml/parser"
-	"github.com/richerve/yaml2go/pkg/codegen"
+	"github.com/richerve/yaml2go/pkg/schema"
 )
 
 // NewASTVisitor is a simple constructor - no test needed
@@ -14,7 +15,7 @@ func TestASTVisitor_Visit(t *testing.T) {
 	tests := []struct {
 		name           string
 		yamlInput      string
-		initialStructs map[string]codegen.StructDef
+		initialStructs map[string]schema.Object
 		initialPath    []string
 		expectedCount  int
 		expectedStruct string
@@ -22,7 +23,7 @@ func TestASTVisitor_Visit(t *testing.T) {
 		{
 			name:           "document node continues traversal",
 			yamlInput:      `name: "test"`,
-			initialStructs: make(map[string]codegen.StructDef),
+			initialStructs: make(map[string]schema.Object),
 			initialPath:    []string{"Root"},
 			expectedCount:  1,
 			expectedStruct: "Root",
@@ -30,7 +31,7 @@ func TestASTVisitor_Visit(t *testing.T) {
 		{
 			name:           "mapping node creates struct",
 			yamlInput:      `{name: "john", age: 30}`,
-			initialStructs: make(map[string]codegen.StructDef),
+			initialStructs: make(map[string]schema.Object),
 			initialPath:    []string{"User"},
 			expectedCount:  1,
 			expectedStruct: "User",
@@ -38,7 +39,7 @@ func TestASTVisitor_Visit(t *testing.T) {
 		{
 			name:           "empty mapping node",
 			yamlInput:      `{}`,
-			initialStructs: make(map[string]codegen.StructDef),
+			initialStructs: make(map[string]schema.Object),
 			initialPath:    []string{"Empty"},
 			expectedCount:  0,
 			expectedStruct: "",
@@ -46,7 +47,7 @@ func TestASTVisitor_Visit(t *testing.T) {
 		{
 			name:           "sequence node stops traversal",
 			yamlInput:      `["item1", "item2"]`,
-			initialStructs: make(map[string]codegen.StructDef),
+			initialStructs: make(map[string]schema.Object),
 			initialPath:    []string{"Items"},
 			expectedCount:  0,
 			expectedStruct: "",
@@ -54,7 +55,7 @@ func TestASTVisitor_Visit(t *testing.T) {
 		{
 			name:           "nested mapping structure",
 			yamlInput:      `{user: {name: "john", profile: {age: 30}}}`,
-			initialStructs: make(map[string]codegen.StructDef),
+			initialStructs: make(map[string]schema.Object),
 			initialPath:    []string{"Document"},
 			expectedCount:  1,
 			expectedStruct: "Document",
@@ -72,7 +73,7 @@ func TestASTVisitor_Visit(t *testing.T) {
 				t.Fatalf("No documents found in parsed YAML")
 			}
 
-			visitor := NewASTVisitor(tt.initialStructs, tt.initialPath, "json", false)
+			visitor := NewASTVisitor(tt.initialStructs, nil, tt.initialPath, Options{})
 			result := visitor.Visit(file.Docs[0].Body)
 
 			// Check if visitor returns correctly
@@ -105,7 +106,7 @@ func TestASTVisitor_visitMappingNode(t *testing.T) {
 		expectedStructs   int
 		expectedFieldName string
 		expectedFieldType string
-		expectOmitEmpty   bool
+		expectEmpty       bool
 	}{
 		{
 			name:              "basic mapping",
@@ -113,8 +114,8 @@ func TestASTVisitor_visitMappingNode(t *testing.T) {
 			path:              []string{"User"},
 			expectedStructs:   1,
 			expectedFieldName: "name",
-			expectedFieldType: "*string",
-			expectOmitEmpty:   false,
+			expectedFieldType: "string",
+			expectEmpty:       false,
 		},
 		{
 			name:              "mapping with empty values",
@@ -122,8 +123,8 @@ func TestASTVisitor_visitMappingNode(t *testing.T) {
 			path:              []string{"Config"},
 			expectedStructs:   1,
 			expectedFieldName: "name",
-			expectedFieldType: "*string",
-			expectOmitEmpty:   true,
+			expectedFieldType: "string",
+			expectEmpty:       true,
 		},
 		{
 			name:            "empty mapping",
@@ -138,7 +139,7 @@ func TestASTVisitor_visitMappingNode(t *testing.T) {
 			expectedStructs:   1,
 			expectedFieldName: "user",
 			expectedFieldType: "User",
-			expectOmitEmpty:   false,
+			expectEmpty:       false,
 		},
 		{
 			name:              "mapping with different field types",
@@ -146,8 +147,8 @@ func TestASTVisitor_visitMappingNode(t *testing.T) {
 			path:              []string{"Item"},
 			expectedStructs:   1,
 			expectedFieldName: "id",
-			expectedFieldType: "*int",
-			expectOmitEmpty:   false,
+			expectedFieldType: "integer",
+			expectEmpty:       false,
 		},
 	}
 
@@ -162,8 +163,8 @@ func TestASTVisitor_visitMappingNode(t *testing.T) {
 				t.Fatalf("No documents found in parsed YAML")
 			}
 
-			structs := make(map[string]codegen.StructDef)
-			visitor := NewASTVisitor(structs, []string{}, "json", false)
+			structs := make(map[string]schema.Object)
+			visitor := NewASTVisitor(structs, nil, []string{}, Options{})
 
 			mappingNode, ok := file.Docs[0].Body.(*ast.MappingNode)
 			if !ok {
@@ -191,24 +192,13 @@ func TestASTVisitor_visitMappingNode(t *testing.T) {
 					if tt.expectedFieldName != "" {
 						found := false
 						for _, field := range structDef.Fields {
-							if field.Name == tt.expectedFieldName {
+							if field.Key == tt.expectedFieldName {
 								found = true
-								if field.Type != tt.expectedFieldType {
+								if field.Type.String() != tt.expectedFieldType {
 									t.Errorf("Expected field type %s, got %s", tt.expectedFieldType, field.Type)
 								}
-								if tt.expectOmitEmpty {
-									hasOmitEmpty := false
-									if field.Tag != nil {
-										for _, flag := range field.Tag.Flags {
-											if flag == "omitempty" {
-												hasOmitEmpty = true
-												break
-											}
-										}
-									}
-									if !hasOmitEmpty {
-										t.Errorf("Expected omitempty flag for field %s", tt.expectedFieldName)
-									}
+								if field.Empty != tt.expectEmpty {
+									t.Errorf("Expected field %s empty %v, got %v", tt.expectedFieldName, tt.expectEmpty, field.Empty)
 								}
 								break
 							}
@@ -271,8 +261,8 @@ func TestASTVisitor_visitMappingValueNode(t *testing.T) {
 				t.Fatalf("No documents found in parsed YAML")
 			}
 
-			structs := make(map[string]codegen.StructDef)
-			visitor := NewASTVisitor(structs, tt.initialPath, "json", false)
+			structs := make(map[string]schema.Object)
+			visitor := NewASTVisitor(structs, nil, tt.initialPath, Options{})
 
 			mappingNode, ok := file.Docs[0].Body.(*ast.MappingNode)
 			if !ok {
@@ -343,8 +333,8 @@ func TestASTVisitor_getCurrentStructName(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			structs := make(map[string]codegen.StructDef)
-			visitor := NewASTVisitor(structs, tt.path, "json", false)
+			structs := make(map[string]schema.Object)
+			visitor := NewASTVisitor(structs, nil, tt.path, Options{})
 			result := visitor.getCurrentStructName()
 
 			if result != tt.expected {
@@ -407,8 +397,8 @@ users:
 				t.Fatalf("No documents found in parsed YAML")
 			}
 
-			structs := make(map[string]codegen.StructDef)
-			visitor := NewASTVisitor(structs, tt.initialPath, "json", false)
+			structs := make(map[string]schema.Object)
+			visitor := NewASTVisitor(structs, nil, tt.initialPath, Options{})
 
 			// Walk the entire document
 			ast.Walk(visitor, file.Docs[0])
@@ -422,7 +412,7 @@ users:
 					if tt.checkField != "" {
 						found := false
 						for _, field := range structDef.Fields {
-							if field.Name == tt.checkField {
+							if field.Key == tt.checkField {
 								found = true
 								break
 							}
@@ -438,3 +428,358 @@ users:
 		})
 	}
 }
+
+func TestASTVisitor_visitSequenceNode(t *testing.T) {
+	tests := []struct {
+		name           string
+		yamlInput      string
+		path           []string
+		expectedStruct string
+		expectedFields []string
+	}{
+		{
+			name:           "fields merged across elements",
+			yamlInput:      `[{name: "a", port: 1}, {name: "b", host: "x"}]`,
+			path:           []string{"Document", "items"},
+			expectedStruct: "Item",
+			expectedFields: []string{"name", "port", "host"},
+		},
+		{
+			name:           "nested sequence elements",
+			yamlInput:      `[[{id: 1}], [{id: 2, tag: "t"}]]`,
+			path:           []string{"Document", "groups"},
+			expectedStruct: "Group",
+			expectedFields: []string{"id", "tag"},
+		},
+		{
+			name:           "scalar elements",
+			yamlInput:      `["a", "b"]`,
+			path:           []string{"Document", "tags"},
+			expectedStruct: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte(tt.yamlInput), 0)
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			sequenceNode, ok := file.Docs[0].Body.(*ast.SequenceNode)
+			if !ok {
+				t.Fatalf("Expected SequenceNode, got %T", file.Docs[0].Body)
+			}
+
+			structs := make(map[string]schema.Object)
+			visitor := NewASTVisitor(structs, nil, tt.path, Options{})
+
+			if result := visitor.visitSequenceNode(sequenceNode); result != nil {
+				t.Errorf("Expected nil visitor from visitSequenceNode, got non-nil")
+			}
+
+			if tt.expectedStruct == "" {
+				if len(structs) != 0 {
+					t.Errorf("Expected no structs, got %d", len(structs))
+				}
+				return
+			}
+
+			structDef, exists := structs[tt.expectedStruct]
+			if !exists {
+				t.Fatalf("Expected struct %s not found", tt.expectedStruct)
+			}
+
+			var fieldNames []string
+			for _, field := range structDef.Fields {
+				fieldNames = append(fieldNames, field.Key)
+			}
+			if strings.Join(fieldNames, ",") != strings.Join(tt.expectedFields, ",") {
+				t.Errorf("Expected fields %v, got %v", tt.expectedFields, fieldNames)
+			}
+		})
+	}
+}
+
+func TestASTVisitor_Diagnostics(t *testing.T) {
+	tests := []struct {
+		name      string
+		yamlInput string
+		expected  []string
+	}{
+		{
+			name:      "valid mapping",
+			yamlInput: `{name: "john", user_id: 1}`,
+			expected:  nil,
+		},
+		{
+			name: "duplicate field names",
+			yamlInput: `
+foo_bar: 1
+foo-bar: 2
+`,
+			expected: []string{
+				`3:1: Document.foo-bar: warning: key "foo-bar" produces field name FooBar already used by key "foo_bar" in struct Document, renamed to FooBar2`,
+			},
+		},
+		{
+			name: "same key in sequence elements",
+			yamlInput: `
+items:
+  - name: a
+  - name: b
+`,
+			expected: nil,
+		},
+		{
+			name: "unsupported node",
+			yamlInput: `
+ratio: *rate
+`,
+			expected: []string{
+				`2:8: Document.ratio: unsupported YAML node Alias`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte(tt.yamlInput), 0)
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			structs := make(map[string]schema.Object)
+			visitor := NewASTVisitor(structs, nil, []string{"Document"}, Options{})
+			ast.Walk(visitor, file.Docs[0])
+
+			var result []string
+			for _, d := range visitor.Diagnostics() {
+				result = append(result, d.Error())
+			}
+
+			if strings.Join(result, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("Diagnostics() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestASTVisitor_Sources(t *testing.T) {
+	yamlInput := `
+name: app
+server:
+  host: localhost
+`
+
+	file, err := parser.ParseBytes([]byte(yamlInput), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	structs := make(map[string]schema.Object)
+	visitor := NewASTVisitor(structs, nil, []string{"Document"}, Options{File: "app.yaml"})
+	ast.Walk(visitor, file.Docs[0])
+
+	expected := map[string]schema.Source{
+		"Document.name":   {File: "app.yaml", Path: "Document.name", Line: 2, Column: 1},
+		"Document.server": {File: "app.yaml", Path: "Document.server", Line: 3, Column: 1},
+		"Server.host":     {File: "app.yaml", Path: "Document.server.host", Line: 4, Column: 3},
+		"Server":          {File: "app.yaml", Path: "Document.server", Line: 4, Column: 7},
+		"Document":        {File: "app.yaml", Path: "Document", Line: 2, Column: 5},
+	}
+
+	result := make(map[string]schema.Source)
+	for name, o := range structs {
+		result[name] = o.Source
+		for _, field := range o.Fields {
+			result[name+"."+field.Key] = field.Source
+		}
+	}
+
+	for key, source := range expected {
+		if result[key] != source {
+			t.Errorf("Source of %s = %+v, want %+v", key, result[key], source)
+		}
+	}
+}
+
+func TestASTVisitor_Samples(t *testing.T) {
+	yamlInput := `
+name: ""
+port: 0
+note: null
+---
+name: app
+port: 0
+note: a
+`
+
+	file, err := parser.ParseBytes([]byte(yamlInput), 0)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		opts     Options
+		expected map[string]bool
+	}{
+		{
+			name:     "empty values optional",
+			opts:     Options{},
+			expected: map[string]bool{"name": true, "port": true, "note": true},
+		},
+		{
+			name:     "samples present in every document",
+			opts:     Options{Samples: true},
+			expected: map[string]bool{"name": false, "port": false, "note": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			structs := make(map[string]schema.Object)
+			for _, doc := range file.Docs {
+				ast.Walk(NewASTVisitor(structs, nil, []string{"Document"}, tt.opts), doc)
+			}
+
+			for _, field := range structs["Document"].Fields {
+				if field.Optional != tt.expected[field.Key] {
+					t.Errorf("Optional of %s = %v, want %v", field.Key, field.Optional, tt.expected[field.Key])
+				}
+			}
+		})
+	}
+}
+
+func TestMergeFields(t *testing.T) {
+	str := schema.Type{Kind: schema.KindString}
+	integer := schema.Type{Kind: schema.KindInteger}
+
+	existing := []schema.Field{
+		{Key: "name", Type: str},
+		{Key: "port", Type: integer},
+		{Key: "tag", Type: str},
+	}
+	next := []schema.Field{
+		{Key: "name", Type: str, Empty: true},
+		{Key: "tag", Type: str, Optional: true},
+		{Key: "host", Type: str},
+	}
+
+	expected := []schema.Field{
+		{Key: "name", Type: str, Empty: true},
+		{Key: "port", Type: integer, Optional: true},
+		{Key: "tag", Type: str, Optional: true},
+		{Key: "host", Type: str, Optional: true},
+	}
+
+	result := mergeFields(existing, next)
+	if len(result) != len(expected) {
+		t.Fatalf("Expected %d fields, got %d", len(expected), len(result))
+	}
+	for i := range expected {
+		if result[i].Key != expected[i].Key || !result[i].Type.Equal(expected[i].Type) || result[i].Optional != expected[i].Optional || result[i].Empty != expected[i].Empty {
+			t.Errorf("Field %d = %+v, want %+v", i, result[i], expected[i])
+		}
+	}
+	if existing[1].Optional {
+		t.Errorf("mergeFields modified the existing fields")
+	}
+}
+
+func TestMergeFields_Null(t *testing.T) {
+	null := schema.Type{Kind: schema.KindNull}
+
+	tests := []struct {
+		name     string
+		existing schema.Field
+		next     schema.Field
+		expected string
+	}{
+		{
+			name:     "null then scalar",
+			existing: schema.Field{Key: "port", Type: null, Optional: true},
+			next:     schema.Field{Key: "port", Type: schema.Type{Kind: schema.KindInteger}},
+			expected: "?integer",
+		},
+		{
+			name:     "object then null",
+			existing: schema.Field{Key: "server", Type: schema.ObjectOf("Server")},
+			next:     schema.Field{Key: "server", Type: null, Optional: true},
+			expected: "?Server",
+		},
+		{
+			name:     "null on both sides",
+			existing: schema.Field{Key: "value", Type: null, Optional: true},
+			next:     schema.Field{Key: "value", Type: null, Optional: true},
+			expected: "null",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := mergeFields([]schema.Field{tt.existing}, []schema.Field{tt.next})
+			if len(result) != 1 {
+				t.Fatalf("Expected 1 field, got %d", len(result))
+			}
+			if r := result[0]; r.Type.String() != tt.expected || !r.Optional {
+				t.Errorf("mergeFields() = %+v, want optional %v", r, tt.expected)
+			}
+		})
+	}
+}
+
+func TestKeyComment(t *testing.T) {
+	tests := []struct {
+		name      string
+		yamlInput string
+		expected  string
+	}{
+		{
+			name:      "no comment",
+			yamlInput: "port: 80\n",
+			expected:  "",
+		},
+		{
+			name:      "head comment",
+			yamlInput: "# The port\n# to listen on\nport: 80\n",
+			expected:  "The port\nto listen on",
+		},
+		{
+			name:      "line comment on scalar",
+			yamlInput: "port: 80 # the port\n",
+			expected:  "the port",
+		},
+		{
+			name:      "line comment on mapping key",
+			yamlInput: "server: # the server\n  port: 80\n",
+			expected:  "the server",
+		},
+		{
+			name:      "head and line comments",
+			yamlInput: "# The port\nport: 80 # default\n",
+			expected:  "The port\ndefault",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte(tt.yamlInput), parser.ParseComments)
+			if err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			mappingNode, ok := file.Docs[0].Body.(*ast.MappingNode)
+			if !ok {
+				t.Fatalf("Expected a mapping, got %T", file.Docs[0].Body)
+			}
+
+			result := KeyComment(mappingNode.Values[0])
+			if result != tt.expected {
+				t.Errorf("KeyComment() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}